@@ -0,0 +1,29 @@
+package cincinnaticlient
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SupportedArchs lists the architectures accepted by the Cincinnati graph API.
+var SupportedArchs = []string{"amd64", "arm64", "ppc64le", "s390x", "multi"}
+
+// archAliases maps common architecture names to the ones used by the Cincinnati graph API.
+var archAliases = map[string]string{
+	"x86_64":  "amd64",
+	"aarch64": "arm64",
+}
+
+// NormalizeArch maps common aliases (e.g. x86_64 -> amd64) to the architecture
+// names used by the Cincinnati graph API and rejects unknown architectures.
+func NormalizeArch(arch string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(arch))
+	if alias, ok := archAliases[normalized]; ok {
+		normalized = alias
+	}
+	if !slices.Contains(SupportedArchs, normalized) {
+		return "", fmt.Errorf("unsupported arch %q, expected one of: %s", arch, strings.Join(SupportedArchs, ", "))
+	}
+	return normalized, nil
+}
@@ -0,0 +1,40 @@
+package cincinnaticlient
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeArch(t *testing.T) {
+	tests := []struct {
+		arch          string
+		expected      string
+		expectedError string
+	}{
+		{arch: "amd64", expected: "amd64"},
+		{arch: "x86_64", expected: "amd64"},
+		{arch: "aarch64", expected: "arm64"},
+		{arch: "amd-64", expectedError: `unsupported arch "amd-64", expected one of: amd64, arm64, ppc64le, s390x, multi`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.arch, func(t *testing.T) {
+			arch, err := NormalizeArch(tc.arch)
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if arch != tc.expected {
+				t.Errorf("Expected arch %q, got %q", tc.expected, arch)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package cincinnaticlient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cacheEntry is a single graph response stored by the diskCache.
+type cacheEntry struct {
+	URL       string    `json:"url"`
+	ETag      string    `json:"etag,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Body      []byte    `json:"body"`
+}
+
+// fresh checks if the entry can be served without contacting the server.
+func (e *cacheEntry) fresh(now time.Time) bool {
+	return now.Before(e.ExpiresAt)
+}
+
+// diskCache stores raw graph responses on disk, one file per request URL.
+// Failures to read or write the cache are not fatal, they only cause a refetch.
+type diskCache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+func newDiskCache(dir string, ttl time.Duration) *diskCache {
+	return &diskCache{dir: dir, ttl: ttl, now: time.Now}
+}
+
+// get returns the entry stored for the given URL or nil when there is none.
+func (d *diskCache) get(rawURL string) *cacheEntry {
+	data, err := os.ReadFile(d.path(rawURL))
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != rawURL {
+		return nil
+	}
+	return &entry
+}
+
+// put stores the body for the given URL, honoring the Cache-Control and ETag response headers.
+func (d *diskCache) put(rawURL string, body []byte, header http.Header) {
+	d.store(rawURL, body, header.Get("ETag"), header)
+}
+
+// refresh stores the entry again after the server confirmed it with 304 Not Modified,
+// honoring the Cache-Control response header. The ETag of the entry is kept
+// unless the response carries a new one, since servers don't have to repeat it.
+func (d *diskCache) refresh(entry *cacheEntry, header http.Header) {
+	etag := header.Get("ETag")
+	if etag == "" {
+		etag = entry.ETag
+	}
+	d.store(entry.URL, entry.Body, etag, header)
+}
+
+// store writes the entry of the given URL, unless the Cache-Control header forbids storing it.
+func (d *diskCache) store(rawURL string, body []byte, etag string, header http.Header) {
+	maxAge, noStore := parseCacheControl(header.Get("Cache-Control"), d.ttl)
+	if noStore {
+		_ = os.Remove(d.path(rawURL))
+		return
+	}
+	entry := cacheEntry{
+		URL:       rawURL,
+		ETag:      etag,
+		ExpiresAt: d.now().Add(maxAge),
+		Body:      body,
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(d.dir, 0o755); err != nil {
+		return
+	}
+	_ = os.WriteFile(d.path(rawURL), data, 0o644)
+}
+
+func (d *diskCache) path(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// parseCacheControl returns for how long a response can be served from the cache
+// and whether it must not be stored at all. A missing max-age falls back to the ttl.
+func parseCacheControl(cacheControl string, ttl time.Duration) (time.Duration, bool) {
+	maxAge, noCache := ttl, false
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-store":
+			return 0, true
+		case directive == "no-cache":
+			noCache = true
+		case strings.HasPrefix(directive, "max-age="):
+			if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil && seconds >= 0 {
+				maxAge = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	if noCache {
+		// the response can be stored but must be revalidated before being served
+		return 0, false
+	}
+	return maxAge, false
+}
@@ -0,0 +1,147 @@
+package cincinnaticlient
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFetchGraphWithCache(t *testing.T) {
+	data, err := os.ReadFile("testdata/fetch-graph-valid-response.json")
+	if err != nil {
+		t.Fatalf("Failed to read test data file: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		// header is returned with the 200 response
+		header http.Header
+		// advance moves the cache clock between the two fetches
+		advance time.Duration
+		// notModified makes the server answer 304 to a request with a matching If-None-Match
+		notModified      bool
+		expectedRequests int
+	}{
+		{
+			name:             "second fetch within the TTL hits the cache",
+			advance:          time.Minute,
+			expectedRequests: 1,
+		},
+		{
+			name:             "expired entry is refetched",
+			advance:          2 * time.Hour,
+			expectedRequests: 2,
+		},
+		{
+			name:             "max-age overrides the TTL",
+			header:           http.Header{"Cache-Control": {"max-age=30"}},
+			advance:          time.Minute,
+			expectedRequests: 2,
+		},
+		{
+			name:             "no-store responses are not cached",
+			header:           http.Header{"Cache-Control": {"no-store"}},
+			expectedRequests: 2,
+		},
+		{
+			name:             "expired entry is revalidated using its ETag",
+			header:           http.Header{"Etag": {`"v1"`}},
+			advance:          2 * time.Hour,
+			notModified:      true,
+			expectedRequests: 2,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			requests := 0
+			hClient := &http.Client{
+				Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+					requests++
+					if tc.notModified && req.Header.Get("If-None-Match") == tc.header.Get("Etag") {
+						return &http.Response{
+							StatusCode: http.StatusNotModified,
+							Body:       io.NopCloser(bytes.NewReader(nil)),
+							Header:     make(http.Header),
+						}
+					}
+					header := tc.header.Clone()
+					if header == nil {
+						header = make(http.Header)
+					}
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(bytes.NewReader(data)),
+						Header:     header,
+					}
+				}),
+			}
+			now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			target := New(hClient, WithCache(t.TempDir(), time.Hour))
+			target.cache.now = func() time.Time { return now }
+
+			for i := 0; i < 2; i++ {
+				graph, err := target.fetchGraph(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64")
+				if err != nil {
+					t.Fatalf("fetchGraph returned an error: %v", err)
+				}
+				if len(graph.Nodes) != 2 {
+					t.Errorf("Expected 2 nodes, got %d", len(graph.Nodes))
+				}
+				now = now.Add(tc.advance)
+			}
+			if requests != tc.expectedRequests {
+				t.Errorf("Expected %d requests, got %d", tc.expectedRequests, requests)
+			}
+		})
+	}
+}
+
+func TestFetchGraphWithCacheKeepsETagOnNotModified(t *testing.T) {
+	data, err := os.ReadFile("testdata/fetch-graph-valid-response.json")
+	if err != nil {
+		t.Fatalf("Failed to read test data file: %v", err)
+	}
+	var ifNoneMatch []string
+	hClient := &http.Client{
+		Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+			ifNoneMatch = append(ifNoneMatch, req.Header.Get("If-None-Match"))
+			if req.Header.Get("If-None-Match") == `"v1"` {
+				// the 304 doesn't repeat the ETag
+				return &http.Response{
+					StatusCode: http.StatusNotModified,
+					Body:       io.NopCloser(bytes.NewReader(nil)),
+					Header:     make(http.Header),
+				}
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(data)),
+				Header:     http.Header{"Etag": {`"v1"`}},
+			}
+		}),
+	}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	target := New(hClient, WithCache(t.TempDir(), time.Hour))
+	target.cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		graph, err := target.fetchGraph(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64")
+		if err != nil {
+			t.Fatalf("fetchGraph returned an error: %v", err)
+		}
+		if len(graph.Nodes) != 2 {
+			t.Errorf("Expected 2 nodes, got %d", len(graph.Nodes))
+		}
+		now = now.Add(2 * time.Hour)
+	}
+	expected := []string{"", `"v1"`, `"v1"`}
+	if diff := cmp.Diff(expected, ifNoneMatch); diff != "" {
+		t.Errorf("If-None-Match headers mismatch (-expected +got):\n%s", diff)
+	}
+}
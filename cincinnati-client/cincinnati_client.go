@@ -1,16 +1,28 @@
 package cincinnaticlient
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
+	"path"
+	"regexp"
 	"slices"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/hashicorp/go-version"
+	"golang.org/x/time/rate"
 )
 
 // Graph represents the upgrade graph returned by Cincinnati.
@@ -29,9 +41,26 @@ type Node struct {
 	Metadata map[string]string `json:"metadata"`
 }
 
-// Risk names a single risk associated with a conditional edge.
+// Risk describes a single risk associated with a conditional edge:
+// its name, a human-readable message, a URL with more details
+// and the rules deciding whether a cluster is exposed to it.
 type Risk struct {
-	Name string `json:"name"`
+	Name          string         `json:"name"`
+	Message       string         `json:"message,omitempty"`
+	URL           string         `json:"url,omitempty"`
+	MatchingRules []MatchingRule `json:"matchingRules,omitempty"`
+}
+
+// MatchingRule is a cluster-condition predicate of a risk.
+// Type is either "Always" or "PromQL", in which case PromQL holds the query.
+type MatchingRule struct {
+	Type   string              `json:"type"`
+	PromQL *PromQLMatchingRule `json:"promql,omitempty"`
+}
+
+// PromQLMatchingRule holds the PromQL query of a matching rule.
+type PromQLMatchingRule struct {
+	PromQL string `json:"promql"`
 }
 
 // ConditionalEdge represents one upgrade edge from → to,
@@ -48,13 +77,29 @@ type ConditionalEdges struct {
 	Risks []Risk            `json:"risks"`
 }
 
+// releaseChannelsMetadataKey is the node metadata key listing the channels a release belongs to.
+const releaseChannelsMetadataKey = "io.openshift.upgrades.graph.release.channels"
+
 // Release represents a discovered release for a specific architecture.
-// It includes the version, payload, and available upgrade targets.
+// It includes the version, the channel it was discovered in, payload,
+// available upgrade targets and the node metadata.
+//
+// ConditionalUpgrades maps the target of every conditional edge leaving this release
+// to the risks gating it, regardless of whether the risks were accepted.
+// Accepted conditional targets are also listed in AvailableUpgrades.
+//
+// BelowMin marks releases below the minVersion that are only kept
+// because an edge points to them, see WithKeepEdgeTargetsBelowMin,
+// or because they belong to the start channel, see WithIncludeBelowMinInStart.
 type Release struct {
-	Version           string
-	Arch              string
-	Payload           string
-	AvailableUpgrades []string
+	Version             string            `json:"version"`
+	Channel             string            `json:"channel,omitempty"`
+	Arch                string            `json:"arch"`
+	Payload             string            `json:"payload"`
+	AvailableUpgrades   []string          `json:"availableUpgrades,omitempty"`
+	ConditionalUpgrades map[string][]Risk `json:"conditionalUpgrades,omitempty"`
+	Metadata            map[string]string `json:"metadata,omitempty"`
+	BelowMin            bool              `json:"belowMin,omitempty"`
 }
 
 // SortAvailableUpgrades orders AvailableUpgrades in ascending semantic-version order.
@@ -85,43 +130,324 @@ type VersionReleases map[string]Release
 // ReleasesByChannel maps a channel name to its set of VersionReleases.
 type ReleasesByChannel map[string]VersionReleases
 
+// AllConditionalEdgeRisks can be passed in the allowedConditionalEdgeRisks
+// to accept every conditional edge regardless of its risks.
+// In this mode risk names are not inspected at all, so conditional edges
+// gated on risks unknown to the caller are accepted as well.
+const AllConditionalEdgeRisks = "*"
+
+// DefaultGraphURL is the graph URL of the public OpenShift update service.
+const DefaultGraphURL = "https://api.openshift.com/api/upgrades_info/graph"
+
+// DefaultMaxResponseBytes is the default limit of the size of a graph response, see WithMaxResponseBytes.
+// It is far above the size of the graphs served by the public OpenShift update service.
+const DefaultMaxResponseBytes = 256 << 20
+
+// Version is the version of this tool reported in the default User-Agent.
+// It can be set at build time using -ldflags "-X <package>.Version=<version>".
+var Version = "dev"
+
+// DefaultUserAgent returns the User-Agent sent with graph requests unless set with WithUserAgent.
+func DefaultUserAgent() string {
+	return "cincinnati-installation-versions/" + Version
+}
+
 // Client is the Cincinnati API client that fetches graphs
 // and computes available releases.
 type Client struct {
-	httpClient *http.Client
+	httpClient        *http.Client
+	graphURL          *url.URL
+	userAgent         string
+	requestModifiers  []func(*http.Request)
+	clientTrace       func(*http.Request) *httptrace.ClientTrace
+	rawCapture        func(url string, body []byte)
+	extraQueryParams  map[string]string
+	headers           http.Header
+	graphSource       GraphSource
+	visitHook         func(channel string, nodeCount int)
+	logger            Logger
+	observer          Observer
+	limiter           *rate.Limiter
+	cache             *diskCache
+	riskEvaluator     RiskEvaluator
+	versionConstraint string
+	channelAllowList  []string
+	channelDenyList   []string
+	// additionalChannelPrefixes include the trailing hyphen, like the start channel prefix
+	additionalChannelPrefixes []string
+	maxVersion                string
+	exclusiveMinVersion       bool
+	onlyNewerChannels         bool
+	excludePreReleases        bool
+	sortUpgrades              bool
+	versionParser             func(string) (*version.Version, error)
+	keepEdgeTargetsBelowMin   bool
+	includeBelowMinInStart    bool
+	expandMultiArch           bool
+	excludeVersions           []string
+	minVersion                string
+	requireNonEmptyStart      bool
+	lenientDownstream         bool
+	archParamName             string
+	maxNodesPerChannel        int
+	maxResponseBytes          int64
+	skipInvalidEdges          bool
+	requestBuilder            func(base *url.URL, channel, arch string) (*http.Request, error)
+	proxyURL                  *url.URL
+	tlsConfig                 *tls.Config
+	rootCAFile                string
+	// transportErr is the error configuring the transport, reported by discovery
+	transportErr error
 }
 
-// New returns a Client using the given http.Client.
+// New returns a Client using the given http.Client and options.
 // If httpClient is nil, http.DefaultClient is used.
-func New(httpClient *http.Client) *Client {
+func New(httpClient *http.Client, opts ...Option) *Client {
 	if httpClient == nil {
 		httpClient = http.DefaultClient
 	}
-	return &Client{
-		httpClient: httpClient,
+	defaultGraphURL, _ := url.Parse(DefaultGraphURL)
+	c := &Client{
+		httpClient:       httpClient,
+		graphURL:         defaultGraphURL,
+		userAgent:        DefaultUserAgent(),
+		logger:           nopLogger{},
+		versionParser:    version.NewVersion,
+		archParamName:    "arch",
+		maxResponseBytes: DefaultMaxResponseBytes,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	c.configureTransport()
+	return c
 }
 
 // DiscoverReleases discovers new releases from the startChannels for the given arch.
+// If graphURL is nil, the client's graph URL (DefaultGraphURL unless set with WithGraphURL) is used.
 // It returns a ReleasesByChannel, with keys as full channel names.
+// A channel other than the startChannel the server responds to with 404 Not Found fails the discovery,
+// use DiscoverReleasesWithUnfetchedChannels or WithLenientDownstream to skip it.
 func (c *Client) DiscoverReleases(graphURL *url.URL, startChannel string, arch string, allowedConditionalEdgeRisks []string) (ReleasesByChannel, error) {
-	startChannelPrefix, startChannelVersionStr, err := c.splitChannel(startChannel)
+	d, err := c.discover(graphURL, startChannel, arch, c.maxVersion, allowedConditionalEdgeRisks)
 	if err != nil {
 		return nil, err
 	}
+	return d.releases, nil
+}
 
-	startChannelVersion, err := version.NewVersion(startChannelVersionStr)
+// DiscoveryResult holds the outcome of a discovery made by Discover.
+type DiscoveryResult struct {
+	// Releases holds the discovered releases, keyed by full channel name.
+	Releases ReleasesByChannel
+	// ChannelErrors holds the reason every skipped channel couldn't be fetched, keyed by channel name,
+	// see UnfetchedChannel.
+	ChannelErrors map[string]error
+	Stats         DiscoveryStats
+	// DiscoveredOrder lists the fetched channels like DiscoverReleasesWithChannelOrder.
+	DiscoveredOrder []string
+}
+
+// Discover is like DiscoverReleases but returns everything known about the discovery at once:
+// the releases, the errors of the skipped channels, the statistics and the order of the fetched channels.
+// Like DiscoverReleasesWithUnfetchedChannels, it skips the channels the server responds to with 404 Not Found.
+func (c *Client) Discover(graphURL *url.URL, startChannel string, arch string, allowedConditionalEdgeRisks []string) (*DiscoveryResult, error) {
+	d, err := c.discoverSkippingNotFound(graphURL, startChannel, arch, allowedConditionalEdgeRisks)
 	if err != nil {
 		return nil, err
 	}
-	minVersion := startChannelVersion
+	return &DiscoveryResult{
+		Releases:        d.releases,
+		ChannelErrors:   d.channelErrors,
+		Stats:           d.stats,
+		DiscoveredOrder: d.order,
+	}, nil
+}
+
+// DiscoverReleasesWithGraphs is like DiscoverReleases but also returns
+// the fetched graphs, keyed by channel name.
+func (c *Client) DiscoverReleasesWithGraphs(graphURL *url.URL, startChannel string, arch string, allowedConditionalEdgeRisks []string) (ReleasesByChannel, map[string]*Graph, error) {
+	d, err := c.discover(graphURL, startChannel, arch, c.maxVersion, allowedConditionalEdgeRisks)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d.releases, d.graphs, nil
+}
+
+// DiscoverReleasesWithUnfetchedChannels is like DiscoverReleases but also returns
+// the channels that were discovered but couldn't be fetched, in the order they were visited.
+// Unlike DiscoverReleases, it skips the channels other than the startChannel the server responds to
+// with 404 Not Found instead of failing.
+func (c *Client) DiscoverReleasesWithUnfetchedChannels(graphURL *url.URL, startChannel string, arch string, allowedConditionalEdgeRisks []string) (ReleasesByChannel, []UnfetchedChannel, error) {
+	d, err := c.discoverSkippingNotFound(graphURL, startChannel, arch, allowedConditionalEdgeRisks)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d.releases, d.unfetched, nil
+}
+
+// DiscoveryStats describes the size of the graphs seen during a discovery.
+type DiscoveryStats struct {
+	// ChannelsFetched is the number of channels whose graph was fetched.
+	ChannelsFetched int
+	// Nodes, Edges and ConditionalEdgeGroups are summed over all fetched graphs.
+	Nodes                 int
+	Edges                 int
+	ConditionalEdgeGroups int
+	// ReleasesKept is the number of releases left after filtering, summed over all channels.
+	ReleasesKept int
+}
+
+// DiscoverReleasesWithStats is like DiscoverReleases but also returns statistics about the fetched graphs.
+func (c *Client) DiscoverReleasesWithStats(graphURL *url.URL, startChannel string, arch string, allowedConditionalEdgeRisks []string) (ReleasesByChannel, DiscoveryStats, error) {
+	d, err := c.discover(graphURL, startChannel, arch, c.maxVersion, allowedConditionalEdgeRisks)
+	if err != nil {
+		return nil, DiscoveryStats{}, err
+	}
+	return d.releases, d.stats, nil
+}
+
+// DiscoverReleasesWithChannelOrder is like DiscoverReleases but also returns the fetched channels
+// in breadth-first order, that is, sorted by their distance from the startChannel and then by name.
+// The startChannel comes first, channels that couldn't be fetched are left out.
+func (c *Client) DiscoverReleasesWithChannelOrder(graphURL *url.URL, startChannel string, arch string, allowedConditionalEdgeRisks []string) (ReleasesByChannel, []string, error) {
+	d, err := c.discover(graphURL, startChannel, arch, c.maxVersion, allowedConditionalEdgeRisks)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d.releases, d.order, nil
+}
+
+// UnfetchedChannel describes a channel that was discovered in the metadata
+// of a release but whose graph couldn't be fetched.
+//
+// The channels the server doesn't know about (404 Not Found) are only skipped by
+// DiscoverReleasesWithUnfetchedChannels and Discover, any other failure aborts the discovery unless WithLenientDownstream is used.
+// The channels a GraphSource reports with ErrChannelNotFound are always skipped.
+// The StatusCode is 0 when the failure is not an unexpected status code.
+type UnfetchedChannel struct {
+	Channel    string
+	StatusCode int
+	Reason     string
+}
+
+// discovery holds the outcome of a single discovery.
+type discovery struct {
+	releases  ReleasesByChannel
+	graphs    map[string]*Graph
+	unfetched []UnfetchedChannel
+	// channelErrors holds the errors of the unfetched channels
+	channelErrors map[string]error
+	stats         DiscoveryStats
+	// order lists the fetched channels sorted by depth and then by name
+	order []string
+}
+
+// discover walks the channels reachable from the startChannel and collects their releases.
+// The maxVersion is the ceiling of the discovery, see WithMaxVersion, an empty string means none.
+func (c *Client) discover(graphURL *url.URL, startChannel string, arch string, maxVersion string, allowedConditionalEdgeRisks []string) (*discovery, error) {
+	start, err := c.prepareDiscovery(graphURL, startChannel, arch, maxVersion)
+	if err != nil {
+		return nil, err
+	}
+	return c.discoverFrom(start, startChannel, allowedConditionalEdgeRisks)
+}
+
+// discoverSkippingNotFound is like discover but skips the channels other than the startChannel
+// the server responds to with 404 Not Found, for the callers reporting the unfetched channels.
+func (c *Client) discoverSkippingNotFound(graphURL *url.URL, startChannel string, arch string, allowedConditionalEdgeRisks []string) (*discovery, error) {
+	start, err := c.prepareDiscovery(graphURL, startChannel, arch, c.maxVersion)
+	if err != nil {
+		return nil, err
+	}
+	start.skipNotFoundDownstream = true
+	return c.discoverFrom(start, startChannel, allowedConditionalEdgeRisks)
+}
+
+// discoverFrom is like discover but starts from the already validated inputs.
+func (c *Client) discoverFrom(start *discoveryStart, startChannel string, allowedConditionalEdgeRisks []string) (*discovery, error) {
+	var source GraphSource = &httpGraphSource{client: c, graphURL: start.graphURL, memo: make(graphMemo)}
+	if c.graphSource != nil {
+		source = c.graphSource
+	}
+	if c.expandMultiArch && start.arch == "multi" {
+		return c.walkEachArch(start, startChannel, allowedConditionalEdgeRisks, source)
+	}
+	return c.walk(start, startChannel, allowedConditionalEdgeRisks, source)
+}
+
+// walkEachArch walks the channels reachable from the startChannel for every concrete arch,
+// in the order of SupportedArchs, and combines the outcomes. The channels are keyed by ArchChannel
+// so that the releases of the same version for different archs are kept apart.
+func (c *Client) walkEachArch(start *discoveryStart, startChannel string, allowedConditionalEdgeRisks []string, source GraphSource) (*discovery, error) {
+	combined := &discovery{
+		releases:      make(ReleasesByChannel),
+		graphs:        make(map[string]*Graph),
+		channelErrors: make(map[string]error),
+	}
+	for _, arch := range SupportedArchs {
+		if arch == "multi" {
+			continue
+		}
+		archStart := *start
+		archStart.arch = arch
+		d, err := c.walk(&archStart, startChannel, allowedConditionalEdgeRisks, source)
+		if err != nil {
+			return nil, err
+		}
+		for channel, releases := range d.releases {
+			combined.releases[ArchChannel(channel, arch)] = releases
+		}
+		for channel, graph := range d.graphs {
+			combined.graphs[ArchChannel(channel, arch)] = graph
+		}
+		for _, unfetched := range d.unfetched {
+			unfetched.Channel = ArchChannel(unfetched.Channel, arch)
+			combined.unfetched = append(combined.unfetched, unfetched)
+		}
+		for channel, err := range d.channelErrors {
+			combined.channelErrors[ArchChannel(channel, arch)] = err
+		}
+		for _, channel := range d.order {
+			combined.order = append(combined.order, ArchChannel(channel, arch))
+		}
+		combined.stats.ChannelsFetched += d.stats.ChannelsFetched
+		combined.stats.Nodes += d.stats.Nodes
+		combined.stats.Edges += d.stats.Edges
+		combined.stats.ConditionalEdgeGroups += d.stats.ConditionalEdgeGroups
+		combined.stats.ReleasesKept += d.stats.ReleasesKept
+	}
+	return combined, nil
+}
+
+// ArchChannel returns the key of the releases of the channel for the given arch
+// in the outcome of a discovery expanded with WithExpandMultiArch, e.g. stable-4.16/arm64.
+func ArchChannel(channel, arch string) string {
+	return channel + "/" + arch
+}
+
+// walk walks the channels reachable from the startChannel and collects their releases,
+// getting the graph of every channel from the source.
+// A channel other than the startChannel the source reports with ErrChannelNotFound is skipped and reported as unfetched,
+// like the ones the server responds to with 404 Not Found if start.skipNotFoundDownstream is set.
+func (c *Client) walk(start *discoveryStart, startChannel string, allowedConditionalEdgeRisks []string, source GraphSource) (*discovery, error) {
+	arch, startChannelPrefix, filter := start.arch, start.startChannelPrefix, start.filter
 
 	queue := []string{startChannel}
 	queued := map[string]bool{
 		startChannel: true,
 	}
+	// depth is the number of channels followed from the startChannel to reach a channel
+	depth := map[string]int{
+		startChannel: 0,
+	}
 
-	releasesByChannel := make(ReleasesByChannel)
+	d := &discovery{
+		releases:      make(ReleasesByChannel),
+		graphs:        make(map[string]*Graph),
+		channelErrors: make(map[string]error),
+	}
+	releasesByChannel := d.releases
 	processed := make(map[string]bool)
 
 	for len(queue) > 0 {
@@ -132,178 +458,960 @@ func (c *Client) DiscoverReleases(graphURL *url.URL, startChannel string, arch s
 		}
 		processed[channel] = true
 
-		graph, err := c.fetchGraph(graphURL, channel, arch)
+		graph, err := source.Fetch(context.Background(), channel, arch)
+		statusCode, notFound := channelNotFound(err)
+		// a 404 is only skipped when asked for, while ErrChannelNotFound always is
+		skipNotFound := notFound && (statusCode == 0 || start.skipNotFoundDownstream || c.lenientDownstream)
+		if channel != startChannel && skipNotFound {
+			c.logger.Warn("skipping channel", "channel", channel, "reason", "channel not found")
+			d.unfetched = append(d.unfetched, UnfetchedChannel{Channel: channel, StatusCode: statusCode, Reason: "channel not found"})
+			d.channelErrors[channel] = err
+			continue
+		}
+		if err != nil && c.lenientDownstream && channel != startChannel {
+			var statusCode int
+			var statusErr *StatusError
+			if errors.As(err, &statusErr) {
+				statusCode = statusErr.StatusCode
+			}
+			c.logger.Warn("skipping channel", "channel", channel, "reason", err.Error())
+			d.unfetched = append(d.unfetched, UnfetchedChannel{Channel: channel, StatusCode: statusCode, Reason: err.Error()})
+			d.channelErrors[channel] = err
+			continue
+		}
 		if err != nil {
-			return nil, fmt.Errorf("error fetching %s graph for channel %s: %w", arch, channel, err)
+			return nil, &channelFetchError{channel: channel, arch: arch, err: err}
+		}
+		if c.maxNodesPerChannel > 0 && len(graph.Nodes) > c.maxNodesPerChannel {
+			return nil, fmt.Errorf("%s graph for channel %s has %d nodes, more than the limit of %d", arch, channel, len(graph.Nodes), c.maxNodesPerChannel)
+		}
+		d.graphs[channel] = graph
+		d.order = append(d.order, channel)
+		d.stats.ChannelsFetched++
+		d.stats.Nodes += len(graph.Nodes)
+		d.stats.Edges += len(graph.Edges)
+		d.stats.ConditionalEdgeGroups += len(graph.ConditionalEdges)
+		if c.visitHook != nil {
+			c.visitHook(channel, len(graph.Nodes))
 		}
 
 		if _, ok := releasesByChannel[channel]; !ok {
 			releasesByChannel[channel] = make(VersionReleases)
 		}
 
+		createRelease := c.createRelease
+		if c.includeBelowMinInStart && channel == startChannel {
+			createRelease = c.createStartRelease
+		}
 		for _, node := range graph.Nodes {
-			if r, found := c.createRelease(node, arch, minVersion); found {
+			if r, found := createRelease(node, channel, arch, filter); found {
 				releasesByChannel[channel][r.Version] = r
 			}
-			newChannels := c.discoverNewChannels(node, startChannelPrefix, minVersion)
+			newChannels := c.discoverNewChannels(node, startChannelPrefix, filter)
 			for _, ch := range newChannels {
 				if !queued[ch] && !processed[ch] {
+					c.logger.Info("discovered channel", "channel", ch, "from", channel)
 					queue = append(queue, ch)
 					queued[ch] = true
+					depth[ch] = depth[channel] + 1
 				}
 			}
 		}
+		if c.requireNonEmptyStart && channel == startChannel && len(releasesByChannel[channel]) == 0 {
+			return nil, fmt.Errorf("start channel %s has no %s releases at or above %s", channel, arch, filter.minVersion)
+		}
+		if c.keepEdgeTargetsBelowMin {
+			c.addBelowMinEdgeTargets(graph, channel, arch, releasesByChannel[channel], filter)
+		}
 		if err = c.processEdges(graph, releasesByChannel[channel]); err != nil {
 			return nil, err
 		}
-		c.processConditionalEdges(graph.ConditionalEdges, allowedConditionalEdgeRisks, releasesByChannel[channel])
+		c.processConditionalEdges(graph.ConditionalEdges, c.riskEvaluatorFor(allowedConditionalEdgeRisks), releasesByChannel[channel])
+	}
+	if c.sortUpgrades {
+		if err := sortAllAvailableUpgrades(releasesByChannel); err != nil {
+			return nil, err
+		}
 	}
-	return releasesByChannel, nil
+	for _, releases := range releasesByChannel {
+		d.stats.ReleasesKept += len(releases)
+	}
+	sort.SliceStable(d.order, func(i, j int) bool {
+		if depth[d.order[i]] != depth[d.order[j]] {
+			return depth[d.order[i]] < depth[d.order[j]]
+		}
+		return d.order[i] < d.order[j]
+	})
+	return d, nil
 }
 
+// channelNotFound checks if err reports a channel whose graph doesn't exist, either
+// a 404 Not Found StatusError, whose status code is returned, or ErrChannelNotFound.
+func channelNotFound(err error) (int, bool) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		return statusErr.StatusCode, true
+	}
+	return 0, errors.Is(err, ErrChannelNotFound)
+}
+
+// sortAllAvailableUpgrades sorts the AvailableUpgrades of every release with SortAvailableUpgrades,
+// stopping at the first one holding an invalid version. Unlike sortReleaseUpgrades,
+// which places invalid versions last, it reports them.
+func sortAllAvailableUpgrades(releasesByChannel ReleasesByChannel) error {
+	for _, channel := range releasesByChannel.SortedChannels() {
+		releases := releasesByChannel[channel]
+		for _, ver := range sortedVersions(releases) {
+			r := releases[ver]
+			if err := r.SortAvailableUpgrades(); err != nil {
+				return fmt.Errorf("error sorting upgrades in channel %s: %w", channel, err)
+			}
+			releases[ver] = r
+		}
+	}
+	return nil
+}
+
+// channelFetchError reports that the graph of a channel couldn't be fetched during a walk.
+type channelFetchError struct {
+	channel string
+	arch    string
+	err     error
+}
+
+func (e *channelFetchError) Error() string {
+	return fmt.Sprintf("error fetching %s graph for channel %s: %v", e.arch, e.channel, e.err)
+}
+
+func (e *channelFetchError) Unwrap() error {
+	return e.err
+}
+
+// discoveryStart holds the validated inputs of a discovery.
+type discoveryStart struct {
+	graphURL           *url.URL
+	arch               string
+	startChannelPrefix string
+	filter             *versionFilter
+	// skipNotFoundDownstream makes the walk skip the channels other than the start channel
+	// the server responds to with 404 Not Found, see discoverSkippingNotFound.
+	skipNotFoundDownstream bool
+}
+
+// prepareDiscovery validates the inputs of a discovery and the client's options.
+// If graphURL is nil, the client's graph URL is used.
+func (c *Client) prepareDiscovery(graphURL *url.URL, startChannel string, arch string, maxVersion string) (*discoveryStart, error) {
+	if graphURL == nil {
+		graphURL = c.graphURL
+	}
+	if err := validateGraphURL(graphURL, c.archParamName); err != nil {
+		return nil, err
+	}
+	if c.transportErr != nil {
+		return nil, c.transportErr
+	}
+	start, err := c.prepareWalk(startChannel, arch, maxVersion)
+	if err != nil {
+		return nil, err
+	}
+	start.graphURL = graphURL
+	return start, nil
+}
+
+// prepareWalk validates the inputs of a discovery and the client's options, except for the graph URL.
+func (c *Client) prepareWalk(startChannel string, arch string, maxVersion string) (*discoveryStart, error) {
+	arch, err := NormalizeArch(arch)
+	if err != nil {
+		return nil, err
+	}
+	startChannelPrefix, startChannelVersion, err := c.parseStartChannel(startChannel)
+	if err != nil {
+		return nil, err
+	}
+	minVersion := startChannelVersion
+	filter, err := c.newVersionFilter(minVersion, maxVersion)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateChannelPatterns(c.channelAllowList, c.channelDenyList); err != nil {
+		return nil, err
+	}
+	return &discoveryStart{arch: arch, startChannelPrefix: startChannelPrefix, filter: filter}, nil
+}
+
+// PlanFetches reports the graph URLs a discovery from the startChannel would fetch first,
+// without running the whole discovery. Since the channels to follow are only known from
+// the fetched metadata, it fetches the start channel and returns its URL followed by the
+// URLs of the channels discovered in it, in the order they would be fetched.
+// Channels discovered in those channels are not reported.
+func (c *Client) PlanFetches(graphURL *url.URL, startChannel, arch string) ([]string, error) {
+	start, err := c.prepareDiscovery(graphURL, startChannel, arch, c.maxVersion)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := c.fetchGraph(start.graphURL, startChannel, start.arch)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s graph for channel %s: %w", start.arch, startChannel, err)
+	}
+
+	channels := []string{startChannel}
+	for _, node := range graph.Nodes {
+		for _, ch := range c.discoverNewChannels(node, start.startChannelPrefix, start.filter) {
+			if !slices.Contains(channels, ch) {
+				channels = append(channels, ch)
+			}
+		}
+	}
+	urls := make([]string, 0, len(channels))
+	for _, ch := range channels {
+		u, err := c.graphRequestURL(start.graphURL, ch, start.arch)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, u.String())
+	}
+	return urls, nil
+}
+
+// graphMemo holds the graphs fetched during a single discovery, keyed by URL.
+type graphMemo map[string]*Graph
+
 // fetchGraph fetches the upgrade graph for a given channel and architecture.
+// The architecture is normalized with NormalizeArch before making the request.
 func (c *Client) fetchGraph(u *url.URL, channel, arch string) (*Graph, error) {
+	return c.fetchGraphMemoized(context.Background(), u, channel, arch, nil)
+}
+
+// fetchGraphMemoized is like fetchGraph but serves graphs already present in the memo,
+// and records the fetched ones in it, so that a URL is fetched at most once per discovery.
+// A nil memo disables memoization.
+func (c *Client) fetchGraphMemoized(ctx context.Context, u *url.URL, channel, arch string, memo graphMemo) (*Graph, error) {
+	modURL, err := c.graphRequestURL(u, channel, arch)
+	if err != nil {
+		return nil, err
+	}
+	arch = modURL.Query().Get(c.archParamName)
+
+	if graph, ok := memo[modURL.String()]; ok {
+		return graph, nil
+	}
+
+	req, err := c.buildGraphRequest(ctx, u, modURL, channel, arch)
+	if err != nil {
+		return nil, err
+	}
+
+	var cached *cacheEntry
+	if c.cache != nil {
+		cached = c.cache.get(modURL.String())
+		if cached != nil && cached.fresh(c.cache.now()) {
+			return memo.parse(modURL.String(), cached.Body, c.versionParser)
+		}
+		if cached != nil && cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+	}
+
+	if c.limiter != nil {
+		if err := c.limiter.Wait(req.Context()); err != nil {
+			return nil, fmt.Errorf("error waiting to fetch data from %s: %w", modURL.String(), err)
+		}
+	}
+	c.logger.Debug("fetching graph", "channel", channel, "arch", arch, "url", modURL.String())
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if c.observer != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		c.observer.ObserveFetch(channel, arch, status, time.Since(start))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching data from %s: %w", modURL.String(), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		c.cache.refresh(cached, resp.Header)
+		return memo.parse(modURL.String(), cached.Body, c.versionParser)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: resp.StatusCode, URL: modURL.String()}
+	}
+	var bodyReader io.Reader = resp.Body
+	// the transport only decompresses transparently when it set Accept-Encoding itself
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		gzipReader, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing response from %s: %w", modURL.String(), err)
+		}
+		defer gzipReader.Close()
+		bodyReader = gzipReader
+	}
+	if c.maxResponseBytes > 0 {
+		bodyReader = &limitedReader{r: bodyReader, remaining: c.maxResponseBytes, limit: c.maxResponseBytes}
+	}
+	if c.rawCapture != nil {
+		raw, err := io.ReadAll(bodyReader)
+		if err != nil {
+			return nil, fmt.Errorf("error reading response from %s: %w", modURL.String(), err)
+		}
+		c.rawCapture(modURL.String(), raw)
+		bodyReader = bytes.NewReader(raw)
+	}
+	// the graph is decoded while it is read, the body is only kept when it has to be cached
+	var body *bytes.Buffer
+	if c.cache != nil {
+		body = &bytes.Buffer{}
+		bodyReader = io.TeeReader(bodyReader, body)
+	}
+	graph, err := decodeGraph(bodyReader, modURL.String(), c.versionParser)
+	if err != nil {
+		return nil, err
+	}
+	memo.record(modURL.String(), graph)
+	if c.cache != nil {
+		c.cache.put(modURL.String(), body.Bytes(), resp.Header)
+	}
+	return graph, nil
+}
+
+// limitedReader reads from r until more than limit bytes were read, then it fails
+// instead of returning the rest of the data.
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	limit     int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// only fail if there is more data than the limit
+		var probe [1]byte
+		if n, err := l.r.Read(probe[:]); n == 0 {
+			return 0, err
+		}
+		return 0, fmt.Errorf("response exceeds the limit of %d bytes", l.limit)
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// graphRequestURL returns the URL of the graph for a given channel and architecture.
+// The architecture is normalized with NormalizeArch.
+func (c *Client) graphRequestURL(u *url.URL, channel, arch string) (*url.URL, error) {
 	if u == nil {
 		return nil, fmt.Errorf("cincinnati graph URL is required")
 	}
+	if c.archParamName == "" || c.archParamName == "channel" {
+		return nil, fmt.Errorf("invalid arch query parameter name %q", c.archParamName)
+	}
+	arch, err := NormalizeArch(arch)
+	if err != nil {
+		return nil, err
+	}
 	modURL := *u
 	queryParams := modURL.Query()
-	queryParams.Add("channel", channel)
-	queryParams.Add("arch", arch)
+	for key, value := range c.extraQueryParams {
+		queryParams.Set(key, value)
+	}
+	queryParams.Set("channel", channel)
+	queryParams.Set(c.archParamName, arch)
 	modURL.RawQuery = queryParams.Encode()
+	return &modURL, nil
+}
 
-	req, err := http.NewRequest("GET", modURL.String(), nil)
+// newGraphRequest creates the request fetching the graph from the given URL, bound to ctx,
+// with the client's headers and request modifiers applied and the client trace, if any, attached.
+func (c *Client) newGraphRequest(ctx context.Context, u *url.URL) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("error creating request for %s: %w", modURL.String(), err)
+		return nil, fmt.Errorf("error creating request for %s: %w", u.String(), err)
 	}
-	req.Header.Set("Accept", "application/json")
+	return c.decorateGraphRequest(req), nil
+}
 
-	resp, err := c.httpClient.Do(req)
+// buildGraphRequest creates the request fetching the graph of the given channel and arch, bound to ctx.
+// It uses the request builder set with WithRequestBuilder, if any, with the base graph URL,
+// otherwise it creates a GET request for the modURL, see newGraphRequest.
+func (c *Client) buildGraphRequest(ctx context.Context, base, modURL *url.URL, channel, arch string) (*http.Request, error) {
+	if c.requestBuilder == nil {
+		return c.newGraphRequest(ctx, modURL)
+	}
+	req, err := c.requestBuilder(base, channel, arch)
 	if err != nil {
-		return nil, fmt.Errorf("error fetching data from %s: %w", modURL.String(), err)
+		return nil, fmt.Errorf("error creating request for %s graph for channel %s: %w", arch, channel, err)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error: status %d when fetching data from %s", resp.StatusCode, modURL.String())
+	if req == nil {
+		return nil, fmt.Errorf("request builder returned a nil request for channel %s", channel)
+	}
+	return c.decorateGraphRequest(req.WithContext(ctx)), nil
+}
+
+// decorateGraphRequest applies the client's headers and request modifiers to req
+// and attaches the client trace, if any.
+func (c *Client) decorateGraphRequest(req *http.Request) *http.Request {
+	// the custom headers go first so that they can't replace the ones the client relies on
+	for key, values := range c.headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("User-Agent", c.userAgent)
+	for _, modify := range c.requestModifiers {
+		modify(req)
+	}
+	if c.clientTrace != nil {
+		if trace := c.clientTrace(req); trace != nil {
+			req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+		}
 	}
-	body, err := io.ReadAll(resp.Body)
+	return req
+}
+
+// parse parses the graph JSON fetched from the given URL and,
+// if it is valid, records the graph in the memo.
+func (m graphMemo) parse(rawURL string, body []byte, parseVersion func(string) (*version.Version, error)) (*Graph, error) {
+	graph, err := parseGraph(body, rawURL, parseVersion)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response from %s: %w", modURL.String(), err)
+		return nil, err
+	}
+	m.record(rawURL, graph)
+	return graph, nil
+}
+
+// record records the graph fetched from the given URL in the memo, a nil memo ignores it.
+func (m graphMemo) record(rawURL string, graph *Graph) {
+	if m != nil {
+		m[rawURL] = graph
+	}
+}
+
+// rawGraph is the JSON form of a Graph whose node versions are not parsed yet.
+type rawGraph struct {
+	Nodes []struct {
+		Version  *string           `json:"version"`
+		Payload  string            `json:"payload"`
+		Metadata map[string]string `json:"metadata"`
+	} `json:"nodes"`
+	Edges            [][]int            `json:"edges"`
+	ConditionalEdges []ConditionalEdges `json:"conditionalEdges"`
+	// ConditionalUpdates is the alternative form of the conditional edges returned by some update services,
+	// listing every conditional edge separately along with its risks.
+	ConditionalUpdates []struct {
+		From    string `json:"from"`
+		Release struct {
+			Version string `json:"version"`
+			Image   string `json:"image"`
+		} `json:"release"`
+		Risks []Risk `json:"risks"`
+	} `json:"conditionalUpdates"`
+}
+
+// parseGraph parses the graph JSON fetched from the given URL.
+// Node versions are parsed with parseVersion, nodes without a version are kept with a nil Version.
+// Conditional edges in the conditionalUpdates form are converted to ConditionalEdges holding a single edge.
+func parseGraph(body []byte, rawURL string, parseVersion func(string) (*version.Version, error)) (*Graph, error) {
+	return decodeGraph(bytes.NewReader(body), rawURL, parseVersion)
+}
+
+// decodeGraph is like parseGraph but decodes the graph JSON while reading it from r,
+// so that large responses don't have to be held in memory next to the decoded graph.
+// Anything following the graph JSON is rejected.
+func decodeGraph(r io.Reader, rawURL string, parseVersion func(string) (*version.Version, error)) (*Graph, error) {
+	var raw rawGraph
+	decoder := json.NewDecoder(r)
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("error parsing JSON from %s: %w", rawURL, err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("error parsing JSON from %s: unexpected data after the graph", rawURL)
+	}
+	graph := &Graph{
+		Nodes:            make([]Node, 0, len(raw.Nodes)),
+		Edges:            raw.Edges,
+		ConditionalEdges: raw.ConditionalEdges,
+	}
+	for _, n := range raw.Nodes {
+		node := Node{Payload: n.Payload, Metadata: n.Metadata}
+		if n.Version != nil {
+			v, err := parseVersion(*n.Version)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing JSON from %s: invalid version %q: %w", rawURL, *n.Version, err)
+			}
+			node.Version = v
+		}
+		graph.Nodes = append(graph.Nodes, node)
 	}
-	var graph Graph
-	if err = json.Unmarshal(body, &graph); err != nil {
-		return nil, fmt.Errorf("error parsing JSON from %s: %w", modURL.String(), err)
+	for _, update := range raw.ConditionalUpdates {
+		graph.ConditionalEdges = append(graph.ConditionalEdges, ConditionalEdges{
+			Edges: []ConditionalEdge{{From: update.From, To: update.Release.Version}},
+			Risks: update.Risks,
+		})
 	}
-	return &graph, nil
+	return graph, nil
 }
 
+// validateGraphURL checks that the graph URL uses the http or https scheme
+// and doesn't already carry the channel or arch query parameters.
+func validateGraphURL(u *url.URL, archParamName string) error {
+	if u == nil {
+		return fmt.Errorf("cincinnati graph URL is required")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid cincinnati graph URL %q: scheme must be http or https", u.String())
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid cincinnati graph URL %q: host is required", u.String())
+	}
+	query := u.Query()
+	for _, param := range []string{"channel", archParamName} {
+		if query.Has(param) {
+			return fmt.Errorf("invalid cincinnati graph URL %q: the %s query parameter is set by the client", u.String(), param)
+		}
+	}
+	return nil
+}
+
+// channelVersionRegexp matches the version at the start of the part of a channel name following its prefix.
+// Anything after it, e.g. the "-ec" of early-candidate channels, is ignored.
+var channelVersionRegexp = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*`)
+
 // extractSemVersionFromChannel removes the given prefix from a channel name
 // and creates a semver.Version. For example, for "stable-4.16" with prefix "stable-"
-// it returns a semver version for "4.16".
+// it returns a semver version for "4.16". Suffixes following the version,
+// like in "candidate-4.16-ec", are ignored.
 func (c *Client) extractSemVersionFromChannel(channel, prefix string) (*version.Version, error) {
 	trimmed := strings.TrimSpace(channel[len(prefix):])
-	return version.NewVersion(trimmed)
+	if v := channelVersionRegexp.FindString(trimmed); v != "" {
+		trimmed = v
+	}
+	return c.versionParser(trimmed)
 }
 
 // splitChannel splits the input string into a prefix (including the hyphen)
-// and the version part. It assumes that the input always contains a hyphen.
-func (c *Client) splitChannel(channel string) (string, string, error) {
+// and the version part, e.g. "candidate-4.16-ec" into "candidate-" and "4.16".
+// Suffixes following the version are dropped.
+func splitChannel(channel string) (string, string, error) {
 	idx := strings.Index(channel, "-")
 	// If the hyphen is not found, return an empty prefix and the original input as version.
 	if idx == -1 {
 		return "", channel, fmt.Errorf("invalid channel format: %s", channel)
 	}
 	prefix := channel[:idx+1]
-	version := channel[idx+1:]
+	version := channelVersionRegexp.FindString(channel[idx+1:])
+	if version == "" {
+		return prefix, channel[idx+1:], fmt.Errorf("invalid channel format: %s", channel)
+	}
 	return prefix, version, nil
 }
 
-// isValidVersion checks if the given version is not nil and >= minVersion
-func (c *Client) isValidVersion(v *version.Version, minVersion *version.Version) bool {
-	return v != nil && v.Compare(minVersion) >= 0
+// channelAllowed checks the channel against the allow and deny lists.
+// A channel matching a deny pattern is never allowed, otherwise it is allowed
+// if the allow list is empty or the channel matches one of its patterns.
+func (c *Client) channelAllowed(channel string) bool {
+	if matchesAnyChannelPattern(c.channelDenyList, channel) {
+		return false
+	}
+	return len(c.channelAllowList) == 0 || matchesAnyChannelPattern(c.channelAllowList, channel)
+}
+
+// matchesAnyChannelPattern checks if the channel matches any of the glob patterns.
+// The patterns are expected to be validated with validateChannelPatterns.
+func matchesAnyChannelPattern(patterns []string, channel string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, channel); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// validateChannelPatterns checks that the channel allow and deny lists hold valid glob patterns.
+func validateChannelPatterns(patternLists ...[]string) error {
+	for _, patterns := range patternLists {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid channel pattern %q: %w", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// parseStartChannel splits the start channel into its prefix and version,
+// returning a single descriptive error if it doesn't follow the <prefix>-<version> format.
+func (c *Client) parseStartChannel(startChannel string) (string, *version.Version, error) {
+	prefix, versionStr, err := splitChannel(startChannel)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid start channel %q: expected the <prefix>-<version> format, e.g. stable-4.16", startChannel)
+	}
+	v, err := c.versionParser(versionStr)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid start channel %q: expected the <prefix>-<version> format, e.g. stable-4.16: %w", startChannel, err)
+	}
+	return prefix, v, nil
+}
+
+// versionFilter decides which versions are kept during a single discovery.
+type versionFilter struct {
+	minVersion *version.Version
+	// channelMinVersion is the minVersion channel versions are compared with,
+	// it only differs from minVersion when the minVersion is set with WithMinVersion
+	channelMinVersion *version.Version
+	// exclusiveMin switches the minVersion comparison to strictly greater-than
+	exclusiveMin bool
+	// onlyNewerChannels switches the channelMinVersion comparison to strictly greater-than
+	onlyNewerChannels bool
+	// excludePreReleases drops versions with a pre-release component, e.g. 4.16.1-rc.1
+	excludePreReleases bool
+	constraint         *semver.Constraints
+	// maxVersion is compared only up to maxSegments segments,
+	// so that a ceiling of 4.17 includes all 4.17.z versions.
+	maxVersion  *version.Version
+	maxSegments int
+	excluded    []*version.Version
+}
+
+// newVersionFilter creates a versionFilter for the given minVersion, unless overridden with
+// the configured min version, the given maxVersion, if not empty, and the configured
+// version constraint and excluded versions.
+func (c *Client) newVersionFilter(minVersion *version.Version, maxVersion string) (*versionFilter, error) {
+	f := &versionFilter{minVersion: minVersion, channelMinVersion: minVersion, exclusiveMin: c.exclusiveMinVersion, onlyNewerChannels: c.onlyNewerChannels, excludePreReleases: c.excludePreReleases}
+	if c.minVersion != "" {
+		override, err := version.NewVersion(c.minVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid min version %q: %w", c.minVersion, err)
+		}
+		if override.LessThan(minVersion) {
+			return nil, fmt.Errorf("invalid min version %q: lower than the start channel version %s", c.minVersion, minVersion)
+		}
+		f.minVersion = override
+	}
+	if c.versionConstraint != "" {
+		constraint, err := semver.NewConstraint(c.versionConstraint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %w", c.versionConstraint, err)
+		}
+		f.constraint = constraint
+	}
+	if maxVersion != "" {
+		ceiling, err := c.versionParser(maxVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max version %q: %w", maxVersion, err)
+		}
+		core, _, _ := strings.Cut(strings.TrimPrefix(ceiling.Original(), "v"), "-")
+		core, _, _ = strings.Cut(core, "+")
+		f.maxVersion = ceiling
+		f.maxSegments = len(strings.Split(core, "."))
+	}
+	for _, rawVersion := range c.excludeVersions {
+		excluded, err := version.NewVersion(rawVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid excluded version %q: %w", rawVersion, err)
+		}
+		f.excluded = append(f.excluded, excluded)
+	}
+	return f, nil
+}
+
+// includes checks if the given version is above the minVersion, doesn't exceed
+// the max version, satisfies the version constraint and isn't an excluded version or pre-release.
+func (f *versionFilter) includes(v *version.Version) bool {
+	return f.exclusionReason(v) == ""
+}
+
+// includesIgnoringMin is like includes but doesn't compare the version with the minVersion.
+func (f *versionFilter) includesIgnoringMin(v *version.Version) bool {
+	reason := f.exclusionReason(v)
+	return reason == "" || reason == belowMinReason
+}
+
+// belowMinReason is the exclusionReason of versions below the minVersion.
+const belowMinReason = "below minimum version"
+
+// exclusionReason describes why the given version is not included by the filter.
+// It returns an empty string if the version is included.
+func (f *versionFilter) exclusionReason(v *version.Version) string {
+	switch {
+	case v == nil:
+		return "missing version"
+	case slices.ContainsFunc(f.excluded, v.Equal):
+		return "excluded version"
+	case f.excludePreReleases && v.Prerelease() != "":
+		return "pre-release"
+	case !f.aboveMin(v):
+		return belowMinReason
+	case !f.belowCeiling(v):
+		return "above maximum version"
+	case !f.satisfiesConstraint(v):
+		return "does not satisfy version constraint"
+	}
+	return ""
+}
+
+// aboveMin checks if the given version is not nil and >= minVersion,
+// or > minVersion when the minimum is exclusive.
+func (f *versionFilter) aboveMin(v *version.Version) bool {
+	return f.atLeast(v, f.minVersion)
+}
+
+// channelAboveMin is like aboveMin but compares the version of a channel with the channelMinVersion.
+func (f *versionFilter) channelAboveMin(v *version.Version) bool {
+	if f.onlyNewerChannels {
+		return v != nil && v.Compare(f.channelMinVersion) > 0
+	}
+	return f.atLeast(v, f.channelMinVersion)
+}
+
+// atLeast checks if the given version is not nil and >= min, or > min when the minimum is exclusive.
+func (f *versionFilter) atLeast(v, min *version.Version) bool {
+	if v == nil {
+		return false
+	}
+	if f.exclusiveMin {
+		return v.Compare(min) > 0
+	}
+	return v.Compare(min) >= 0
+}
+
+// belowCeiling checks if the given version doesn't exceed the max version, if any.
+// Only the first maxSegments segments of the version are compared.
+func (f *versionFilter) belowCeiling(v *version.Version) bool {
+	if f.maxVersion == nil {
+		return true
+	}
+	if v == nil {
+		return false
+	}
+	if f.maxSegments >= 3 {
+		return v.Compare(f.maxVersion) <= 0
+	}
+	segments, maxSegments := v.Segments(), f.maxVersion.Segments()
+	for i := 0; i < f.maxSegments; i++ {
+		if segments[i] != maxSegments[i] {
+			return segments[i] < maxSegments[i]
+		}
+	}
+	return true
 }
 
-// processEdges process the cincinnati graph edges and updates AvailableUpgrades
+// satisfiesConstraint checks if the given version satisfies the version constraint, if any.
+func (f *versionFilter) satisfiesConstraint(v *version.Version) bool {
+	if f.constraint == nil {
+		return true
+	}
+	if v == nil {
+		return false
+	}
+	sv, err := semver.NewVersion(v.String())
+	if err != nil {
+		return false
+	}
+	return f.constraint.Check(sv)
+}
+
+// processEdges process the cincinnati graph edges and updates AvailableUpgrades.
+// Only edges between discovered releases are recorded, so edges pointing to versions
+// filtered out of the releases, e.g. below the minVersion, are dropped,
+// as are the edges from or to nodes without a version.
+// The AvailableUpgrades are left in ascending semantic-version order.
+// Malformed edges and edges with out-of-range indices are an error, unless WithSkipInvalidEdges is used.
 func (c *Client) processEdges(graph *Graph, releases VersionReleases) error {
 	for idx, edge := range graph.Edges {
 		if len(edge) < 2 {
+			if c.skipInvalidEdges {
+				c.logger.Warn("dropping edge", "edge", edge, "index", idx, "reason", "invalid edge format")
+				continue
+			}
 			return fmt.Errorf("invalid edge format: expected 2 ints, got: %v", edge)
 		}
 		fromIdx, toIdx := edge[0], edge[1]
 		if fromIdx < 0 || fromIdx >= len(graph.Nodes) || toIdx < 0 || toIdx >= len(graph.Nodes) {
+			if c.skipInvalidEdges {
+				c.logger.Warn("dropping edge", "edge", edge, "index", idx, "reason", "invalid edge indices")
+				continue
+			}
 			return fmt.Errorf("invalid edge indices: %v at index: %d", edge, idx)
 		}
+		if graph.Nodes[fromIdx].Version == nil || graph.Nodes[toIdx].Version == nil {
+			c.logger.Warn("dropping edge", "edge", edge, "reason", "node without version")
+			continue
+		}
 		fromVerStr := graph.Nodes[fromIdx].Version.String()
+		toVerStr := graph.Nodes[toIdx].Version.String()
+		if _, ok := releases[toVerStr]; !ok {
+			continue
+		}
 		if r, ok := releases[fromVerStr]; ok {
-			toVerStr := graph.Nodes[toIdx].Version.String()
-			if !slices.Contains(r.AvailableUpgrades, toVerStr) {
+			if !r.HasUpgradeTo(toVerStr) {
 				r.AvailableUpgrades = append(r.AvailableUpgrades, toVerStr)
 				releases[fromVerStr] = r
 			}
 		}
 	}
+	sortReleaseUpgrades(releases)
 	return nil
 }
 
+// sortReleaseUpgrades sorts the AvailableUpgrades of every release in ascending semantic-version order,
+// so that they don't depend on the order of the edges of the graph.
+func sortReleaseUpgrades(releases VersionReleases) {
+	for _, r := range releases {
+		SortVersions(r.AvailableUpgrades)
+	}
+}
+
 // processConditionalEdges processes conditional edges.
-// For each conditional edge group, it checks that every risk in the group is accepted.
-// Only if all risks are accepted, the function adds the upgrade to the AvailableUpgrades.
-func (c *Client) processConditionalEdges(conditionalEdges []ConditionalEdges, allowedConditionalEdgeRisks []string, releases VersionReleases) {
+// Every conditional edge is recorded in the ConditionalUpgrades along with its risks.
+// For each conditional edge group, it checks that the evaluator accepts every risk in the group.
+// Only if all risks are accepted, the function adds the upgrade to the AvailableUpgrades,
+// keeping them in ascending semantic-version order.
+// Like in processEdges, only edges between discovered releases are recorded.
+func (c *Client) processConditionalEdges(conditionalEdges []ConditionalEdges, evaluator RiskEvaluator, releases VersionReleases) {
 	for _, group := range conditionalEdges {
-		allAccepted := true
-		for _, risk := range group.Risks {
-			if !slices.Contains(allowedConditionalEdgeRisks, risk.Name) {
-				allAccepted = false
-				break
+		accepted := c.risksAccepted(group.Risks, evaluator)
+		for _, edge := range group.Edges {
+			fromVerStr := c.normalizeVersion(edge.From)
+			toVerStr := c.normalizeVersion(edge.To)
+			r, ok := releases[fromVerStr]
+			if !ok {
+				continue
 			}
+			if _, ok := releases[toVerStr]; !ok {
+				continue
+			}
+			if r.ConditionalUpgrades == nil {
+				r.ConditionalUpgrades = make(map[string][]Risk)
+			}
+			r.ConditionalUpgrades[toVerStr] = appendRisks(r.ConditionalUpgrades[toVerStr], group.Risks...)
+			if accepted && !r.HasUpgradeTo(toVerStr) {
+				r.AvailableUpgrades = append(r.AvailableUpgrades, toVerStr)
+			}
+			releases[fromVerStr] = r
 		}
-		if !allAccepted {
-			continue
-		}
+	}
+	sortReleaseUpgrades(releases)
+}
 
-		for _, edge := range group.Edges {
-			fromVerStr := edge.From
-			toVerStr := edge.To
-			if r, ok := releases[fromVerStr]; ok {
-				if !slices.Contains(r.AvailableUpgrades, toVerStr) {
-					r.AvailableUpgrades = append(r.AvailableUpgrades, toVerStr)
-					releases[fromVerStr] = r
-				}
-			}
+// normalizeVersion returns the version as parsed by the client's version parser,
+// so that it matches the versions of the nodes. Versions that cannot be parsed are returned as is.
+func (c *Client) normalizeVersion(v string) string {
+	parsed, err := c.versionParser(v)
+	if err != nil {
+		return v
+	}
+	return parsed.String()
+}
+
+// risksAccepted checks if the evaluator accepts every risk.
+func (c *Client) risksAccepted(risks []Risk, evaluator RiskEvaluator) bool {
+	for _, risk := range risks {
+		if !evaluator.AcceptRisk(risk) {
+			return false
 		}
 	}
+	return true
+}
+
+// riskEvaluatorFor returns the configured RiskEvaluator,
+// or an AllowListRiskEvaluator for the given risks when none was configured.
+func (c *Client) riskEvaluatorFor(allowedConditionalEdgeRisks []string) RiskEvaluator {
+	if c.riskEvaluator != nil {
+		return c.riskEvaluator
+	}
+	return AllowListRiskEvaluator(allowedConditionalEdgeRisks)
 }
 
-// createRelease simply creates a release from the given node.
-func (c *Client) createRelease(node Node, arch string, minVersion *version.Version) (Release, bool) {
-	if !c.isValidVersion(node.Version, minVersion) {
+// createRelease simply creates a release from the given node found in the given channel.
+// It returns false if the node's version is not included by the filter.
+func (c *Client) createRelease(node Node, channel, arch string, filter *versionFilter) (Release, bool) {
+	if reason := filter.exclusionReason(node.Version); reason != "" {
+		if node.Version == nil {
+			c.logger.Debug("skipping node", "channel", channel, "payload", node.Payload, "reason", reason)
+		} else {
+			c.logger.Debug("skipping node", "channel", channel, "version", node.Version.String(), "reason", reason)
+		}
 		return Release{}, false
 	}
+	return c.newRelease(node, channel, arch), true
+}
+
+// createStartRelease is like createRelease but also creates releases, flagged BelowMin,
+// from the nodes below the minVersion, see WithIncludeBelowMinInStart. The other filters still apply.
+func (c *Client) createStartRelease(node Node, channel, arch string, filter *versionFilter) (Release, bool) {
+	if node.Version != nil && !filter.aboveMin(node.Version) && filter.includesIgnoringMin(node.Version) {
+		r := c.newRelease(node, channel, arch)
+		r.BelowMin = true
+		return r, true
+	}
+	return c.createRelease(node, channel, arch, filter)
+}
+
+// newRelease creates a release from the given node found in the given channel.
+// The node must have a version.
+func (c *Client) newRelease(node Node, channel, arch string) Release {
 	r := Release{
 		Version: node.Version.String(),
+		Channel: channel,
 		Arch:    arch,
 		Payload: node.Payload,
 	}
-	return r, true
+	if len(node.Metadata) > 0 {
+		r.Metadata = maps.Clone(node.Metadata)
+	}
+	return r
+}
+
+// addBelowMinEdgeTargets adds the nodes below the minVersion that are targets of edges
+// leaving the discovered releases as releases flagged BelowMin, so that processEdges keeps the edges.
+// The other filters still apply to them. Invalid edges are left for processEdges to report.
+func (c *Client) addBelowMinEdgeTargets(graph *Graph, channel, arch string, releases VersionReleases, filter *versionFilter) {
+	for _, edge := range graph.Edges {
+		if len(edge) < 2 || edge[0] < 0 || edge[0] >= len(graph.Nodes) || edge[1] < 0 || edge[1] >= len(graph.Nodes) {
+			continue
+		}
+		from, to := graph.Nodes[edge[0]], graph.Nodes[edge[1]]
+		if from.Version == nil || to.Version == nil || filter.aboveMin(to.Version) {
+			continue
+		}
+		if _, ok := releases[from.Version.String()]; !ok {
+			continue
+		}
+		if _, ok := releases[to.Version.String()]; ok || !filter.includesIgnoringMin(to.Version) {
+			continue
+		}
+		r := c.newRelease(to, channel, arch)
+		r.BelowMin = true
+		releases[r.Version] = r
+	}
 }
 
 // discoverNewChannels checks node's metadata and returns new channels that match the condition.
-func (c *Client) discoverNewChannels(node Node, startChannelPrefix string, minVersion *version.Version) []string {
+// Only channels sharing the start channel prefix or one of the additional channel prefixes are followed.
+// Channels whose version is below the minVersion or above the max version are skipped.
+func (c *Client) discoverNewChannels(node Node, startChannelPrefix string, filter *versionFilter) []string {
 	var newCh []string
-	meta, ok := node.Metadata["io.openshift.upgrades.graph.release.channels"]
-	if !ok {
-		return newCh
-	}
-	for _, ch := range strings.Split(meta, ",") {
-		ch = strings.TrimSpace(ch)
-		if strings.HasPrefix(ch, startChannelPrefix) {
-			channelVer, err := c.extractSemVersionFromChannel(ch, startChannelPrefix)
-			if err != nil {
+	prefixes := append([]string{startChannelPrefix}, c.additionalChannelPrefixes...)
+	for _, ch := range node.Channels() {
+		for _, prefix := range prefixes {
+			if !strings.HasPrefix(ch, prefix) {
 				continue
 			}
-			if c.isValidVersion(channelVer, minVersion) {
+			channelVer, err := c.extractSemVersionFromChannel(ch, prefix)
+			if err != nil {
+				break
+			}
+			if filter.channelAboveMin(channelVer) && filter.belowCeiling(channelVer) && c.channelAllowed(ch) {
 				newCh = append(newCh, ch)
 			}
+			break
 		}
 	}
 	return newCh
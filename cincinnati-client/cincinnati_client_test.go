@@ -2,11 +2,16 @@ package cincinnaticlient
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
@@ -71,6 +76,37 @@ func TestFetchGraph(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		{
+			name:        "arch alias is normalized",
+			graphURL:    rawURLtoURLOrDie("https://api.openshift.com/api/upgrades_info/graph"),
+			inputFile:   "testdata/fetch-graph-valid-response.json",
+			channel:     "stable-4.16",
+			arch:        "x86_64",
+			statusCode:  200,
+			expectedURL: "https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.16",
+			expectedGraph: &Graph{
+				Nodes: []Node{
+					{
+						Version:  versionOrDie("4.16.1"),
+						Payload:  "example-payload",
+						Metadata: map[string]string{"io.openshift.upgrades.graph.release.channels": "stable-4.16,fast-4.16"},
+					},
+					{
+						Version:  versionOrDie("4.16.2"),
+						Payload:  "another-payload",
+						Metadata: map[string]string{"io.openshift.upgrades.graph.release.channels": "stable-4.16"},
+					},
+				},
+			},
+		},
+		{
+			name:          "unknown arch is rejected",
+			graphURL:      rawURLtoURLOrDie("https://api.openshift.com/api/upgrades_info/graph"),
+			inputFile:     "testdata/fetch-graph-valid-response.json",
+			channel:       "stable-4.16",
+			arch:          "amd-64",
+			expectedError: `unsupported arch "amd-64", expected one of: amd64, arm64, ppc64le, s390x, multi`,
+		},
 		{
 			name:          "invalid JSON response",
 			graphURL:      rawURLtoURLOrDie("https://api.openshift.com/api/upgrades_info/graph"),
@@ -140,6 +176,141 @@ func TestFetchGraph(t *testing.T) {
 	}
 }
 
+func TestFetchGraphGzip(t *testing.T) {
+	data, err := os.ReadFile("testdata/fetch-graph-valid-response.json")
+	if err != nil {
+		t.Fatalf("Failed to read test data file: %v", err)
+	}
+	var compressed bytes.Buffer
+	gw := gzip.NewWriter(&compressed)
+	if _, err := gw.Write(data); err != nil {
+		t.Fatalf("Failed to compress test data: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("Failed to compress test data: %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		body            []byte
+		contentEncoding string
+	}{
+		{
+			name:            "gzip-encoded response",
+			body:            compressed.Bytes(),
+			contentEncoding: "gzip",
+		},
+		{
+			name: "uncompressed response",
+			body: data,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hClient := &http.Client{
+				Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+					if got := req.Header.Get("Accept-Encoding"); got != "gzip" {
+						t.Errorf("Expected Accept-Encoding %q, got %q", "gzip", got)
+					}
+					header := make(http.Header)
+					if tc.contentEncoding != "" {
+						header.Set("Content-Encoding", tc.contentEncoding)
+					}
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       ioutil.NopCloser(bytes.NewReader(tc.body)),
+						Header:     header,
+					}
+				}),
+			}
+
+			graph, err := New(hClient).fetchGraph(rawURLtoURLOrDie("https://api.openshift.com/api/upgrades_info/graph"), "stable-4.16", "amd64")
+			if err != nil {
+				t.Fatalf("fetchGraph returned an error: %v", err)
+			}
+			if len(graph.Nodes) != 2 || graph.Nodes[0].Version.String() != "4.16.1" || graph.Nodes[1].Version.String() != "4.16.2" {
+				t.Errorf("Unexpected graph nodes: %+v", graph.Nodes)
+			}
+		})
+	}
+}
+
+func TestFetchGraphLargeResponse(t *testing.T) {
+	const nodes = 20000
+	var large bytes.Buffer
+	large.WriteString(`{"nodes":[`)
+	for i := 0; i < nodes; i++ {
+		if i > 0 {
+			large.WriteString(",")
+		}
+		fmt.Fprintf(&large, `{"version":"4.16.%d","payload":"quay.io/openshift-release-dev/ocp-release@sha256:%064d","metadata":{}}`, i, i)
+	}
+	large.WriteString(`],"edges":[`)
+	for i := 0; i < nodes-1; i++ {
+		if i > 0 {
+			large.WriteString(",")
+		}
+		fmt.Fprintf(&large, "[%d,%d]", i, i+1)
+	}
+	large.WriteString(`]}`)
+
+	tests := []struct {
+		name          string
+		body          []byte
+		expectedError string
+	}{
+		{
+			name: "large graph",
+			body: large.Bytes(),
+		},
+		{
+			name:          "truncated graph",
+			body:          large.Bytes()[:large.Len()/2],
+			expectedError: "error parsing JSON",
+		},
+		{
+			name:          "trailing data after the graph",
+			body:          append(bytes.Clone(large.Bytes()), `{"nodes":[]}`...),
+			expectedError: "error parsing JSON",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hClient := &http.Client{
+				Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       ioutil.NopCloser(bytes.NewReader(tc.body)),
+						Header:     make(http.Header),
+					}
+				}),
+			}
+
+			graph, err := New(hClient).fetchGraph(rawURLtoURLOrDie("https://api.openshift.com/api/upgrades_info/graph"), "stable-4.16", "amd64")
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetchGraph returned an error: %v", err)
+			}
+			if len(graph.Nodes) != nodes || len(graph.Edges) != nodes-1 {
+				t.Fatalf("Expected %d nodes and %d edges, got %d nodes and %d edges", nodes, nodes-1, len(graph.Nodes), len(graph.Edges))
+			}
+			if got := graph.Nodes[nodes-1].Version.String(); got != fmt.Sprintf("4.16.%d", nodes-1) {
+				t.Errorf("Unexpected version of the last node: %s", got)
+			}
+		})
+	}
+}
+
 func TestDiscoverReleases(t *testing.T) {
 	type fileResponse struct {
 		filename   string
@@ -168,9 +339,11 @@ func TestDiscoverReleases(t *testing.T) {
 			expected: ReleasesByChannel{
 				"stable-4.16": {
 					"4.16.2": Release{
-						Version: "4.16.2",
-						Arch:    "amd64",
-						Payload: "payload-stable",
+						Version:  "4.16.2",
+						Channel:  "stable-4.16",
+						Arch:     "amd64",
+						Payload:  "payload-stable",
+						Metadata: map[string]string{"io.openshift.upgrades.graph.release.channels": "stable-4.16,fast-4.16"},
 					},
 				},
 			},
@@ -187,6 +360,7 @@ func TestDiscoverReleases(t *testing.T) {
 				"stable-4.16": {
 					"4.16.2": Release{
 						Version: "4.16.2",
+						Channel: "stable-4.16",
 						Arch:    "amd64",
 						Payload: "payload-stable",
 					},
@@ -207,14 +381,17 @@ func TestDiscoverReleases(t *testing.T) {
 			expected: ReleasesByChannel{
 				"stable-4.16": {
 					"4.16.2": Release{
-						Version: "4.16.2",
-						Arch:    "amd64",
-						Payload: "payload-stable",
+						Version:  "4.16.2",
+						Channel:  "stable-4.16",
+						Arch:     "amd64",
+						Payload:  "payload-stable",
+						Metadata: map[string]string{"io.openshift.upgrades.graph.release.channels": "stable-4.17,stable-4.18"},
 					},
 				},
 				"stable-4.17": {
 					"4.17.5": Release{
 						Version: "4.17.5",
+						Channel: "stable-4.17",
 						Arch:    "amd64",
 						Payload: "payload-4.17",
 					},
@@ -222,6 +399,7 @@ func TestDiscoverReleases(t *testing.T) {
 				"stable-4.18": {
 					"4.18.1": Release{
 						Version: "4.18.1",
+						Channel: "stable-4.18",
 						Arch:    "amd64",
 						Payload: "payload-4.18",
 					},
@@ -240,18 +418,21 @@ func TestDiscoverReleases(t *testing.T) {
 				"stable-4.16": VersionReleases{
 					"4.16.1": Release{
 						Version:           "4.16.1",
+						Channel:           "stable-4.16",
 						Arch:              "amd64",
 						Payload:           "payload-4.16.1",
 						AvailableUpgrades: []string{"4.16.2", "4.16.5"},
 					},
 					"4.16.2": Release{
 						Version:           "4.16.2",
+						Channel:           "stable-4.16",
 						Arch:              "amd64",
 						Payload:           "payload-4.16.2",
 						AvailableUpgrades: []string{"4.16.5"},
 					},
 					"4.16.5": Release{
 						Version: "4.16.5",
+						Channel: "stable-4.16",
 						Arch:    "amd64",
 						Payload: "payload-4.16.5",
 					},
@@ -270,13 +451,16 @@ func TestDiscoverReleases(t *testing.T) {
 			expected: ReleasesByChannel{
 				"stable-4.16": VersionReleases{
 					"4.16.1": Release{
-						Version:           "4.16.1",
-						Arch:              "amd64",
-						Payload:           "payload-4.16.1",
-						AvailableUpgrades: []string{"4.16.3"},
+						Version:             "4.16.1",
+						Channel:             "stable-4.16",
+						Arch:                "amd64",
+						Payload:             "payload-4.16.1",
+						AvailableUpgrades:   []string{"4.16.3"},
+						ConditionalUpgrades: map[string][]Risk{"4.16.3": {{Name: "RiskA"}, {Name: "RiskB"}}},
 					},
 					"4.16.3": Release{
 						Version: "4.16.3",
+						Channel: "stable-4.16",
 						Arch:    "amd64",
 						Payload: "payload-4.16.3",
 					},
@@ -284,23 +468,84 @@ func TestDiscoverReleases(t *testing.T) {
 			},
 		},
 		{
-			name:                        "conditional edges, partial allowed",
+			name:                        "conditional edges, all risks accepted",
 			graphURL:                    rawURLtoURLOrDie("https://api.openshift.com/api/upgrades_info/graph"),
 			startChannel:                "stable-4.16",
 			arch:                        "amd64",
-			allowedConditionalEdgeRisks: []string{"RiskA"},
+			allowedConditionalEdgeRisks: []string{AllConditionalEdgeRisks},
 			responses: map[string]fileResponse{
 				"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.16": {filename: "testdata/discover-releases-stable-4.16-conditional-edges.json", statusCode: 200},
 			},
+			expected: ReleasesByChannel{
+				"stable-4.16": VersionReleases{
+					"4.16.1": Release{
+						Version:             "4.16.1",
+						Channel:             "stable-4.16",
+						Arch:                "amd64",
+						Payload:             "payload-4.16.1",
+						AvailableUpgrades:   []string{"4.16.3"},
+						ConditionalUpgrades: map[string][]Risk{"4.16.3": {{Name: "RiskA"}, {Name: "RiskB"}}},
+					},
+					"4.16.3": Release{
+						Version: "4.16.3",
+						Channel: "stable-4.16",
+						Arch:    "amd64",
+						Payload: "payload-4.16.3",
+					},
+				},
+			},
+		},
+		{
+			name:         "conditional edges, risk message and URL are retained",
+			graphURL:     rawURLtoURLOrDie("https://api.openshift.com/api/upgrades_info/graph"),
+			startChannel: "stable-4.16",
+			arch:         "amd64",
+			responses: map[string]fileResponse{
+				"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.16": {filename: "testdata/discover-releases-stable-4.16-conditional-edges-risk-details.json", statusCode: 200},
+			},
 			expected: ReleasesByChannel{
 				"stable-4.16": VersionReleases{
 					"4.16.1": Release{
 						Version: "4.16.1",
+						Channel: "stable-4.16",
 						Arch:    "amd64",
 						Payload: "payload-4.16.1",
+						ConditionalUpgrades: map[string][]Risk{"4.16.3": {{
+							Name:    "RiskA",
+							Message: "Clusters using the RiskA feature may fail to upgrade.",
+							URL:     "https://issues.example.com/RISKA-1",
+						}}},
+					},
+					"4.16.3": Release{
+						Version: "4.16.3",
+						Channel: "stable-4.16",
+						Arch:    "amd64",
+						Payload: "payload-4.16.3",
+					},
+				},
+			},
+		},
+		{
+			name:                        "conditional edges, partial allowed",
+			graphURL:                    rawURLtoURLOrDie("https://api.openshift.com/api/upgrades_info/graph"),
+			startChannel:                "stable-4.16",
+			arch:                        "amd64",
+			allowedConditionalEdgeRisks: []string{"RiskA"},
+			responses: map[string]fileResponse{
+				"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.16": {filename: "testdata/discover-releases-stable-4.16-conditional-edges.json", statusCode: 200},
+			},
+			expected: ReleasesByChannel{
+				"stable-4.16": VersionReleases{
+					"4.16.1": Release{
+						Version:             "4.16.1",
+						Channel:             "stable-4.16",
+						Arch:                "amd64",
+						Payload:             "payload-4.16.1",
+						ConditionalUpgrades: map[string][]Risk{"4.16.3": {{Name: "RiskA"}, {Name: "RiskB"}}},
 					},
 					"4.16.3": Release{
 						Version: "4.16.3",
+						Channel: "stable-4.16",
 						Arch:    "amd64",
 						Payload: "payload-4.16.3",
 					},
@@ -319,12 +564,95 @@ func TestDiscoverReleases(t *testing.T) {
 			expected: ReleasesByChannel{
 				"stable-4.16": VersionReleases{
 					"4.16.1": Release{
-						Version: "4.16.1",
+						Version:             "4.16.1",
+						Channel:             "stable-4.16",
+						Arch:                "amd64",
+						Payload:             "payload-4.16.1",
+						ConditionalUpgrades: map[string][]Risk{"4.16.3": {{Name: "RiskA"}, {Name: "RiskB"}}},
+					},
+					"4.16.3": Release{
+						Version: "4.16.3",
+						Channel: "stable-4.16",
 						Arch:    "amd64",
-						Payload: "payload-4.16.1",
+						Payload: "payload-4.16.3",
+					},
+				},
+			},
+		},
+		{
+			name:         "edges from or to a node without a version are dropped",
+			graphURL:     rawURLtoURLOrDie("https://api.openshift.com/api/upgrades_info/graph"),
+			startChannel: "stable-4.16",
+			arch:         "amd64",
+			responses: map[string]fileResponse{
+				"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.16": {filename: "testdata/discover-releases-stable-4.16-missing-version.json", statusCode: 200},
+			},
+			expected: ReleasesByChannel{
+				"stable-4.16": VersionReleases{
+					"4.16.1": Release{
+						Version:           "4.16.1",
+						Channel:           "stable-4.16",
+						Arch:              "amd64",
+						Payload:           "payload-4.16.1",
+						AvailableUpgrades: []string{"4.16.2"},
+					},
+					"4.16.2": Release{
+						Version: "4.16.2",
+						Channel: "stable-4.16",
+						Arch:    "amd64",
+						Payload: "payload-4.16.2",
+					},
+				},
+			},
+		},
+		{
+			name:         "edges pointing to versions below the minVersion are dropped",
+			graphURL:     rawURLtoURLOrDie("https://api.openshift.com/api/upgrades_info/graph"),
+			startChannel: "stable-4.16",
+			arch:         "amd64",
+			responses: map[string]fileResponse{
+				"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.16": {filename: "testdata/discover-releases-stable-4.16-below-min-target.json", statusCode: 200},
+			},
+			expected: ReleasesByChannel{
+				"stable-4.16": VersionReleases{
+					"4.16.1": Release{
+						Version:           "4.16.1",
+						Channel:           "stable-4.16",
+						Arch:              "amd64",
+						Payload:           "payload-4.16.1",
+						AvailableUpgrades: []string{"4.16.2"},
+					},
+					"4.16.2": Release{
+						Version: "4.16.2",
+						Channel: "stable-4.16",
+						Arch:    "amd64",
+						Payload: "payload-4.16.2",
+					},
+				},
+			},
+		},
+		{
+			name:                        "conditional edges pointing to versions below the minVersion are dropped even if all risks are accepted",
+			graphURL:                    rawURLtoURLOrDie("https://api.openshift.com/api/upgrades_info/graph"),
+			startChannel:                "stable-4.16",
+			arch:                        "amd64",
+			allowedConditionalEdgeRisks: []string{AllConditionalEdgeRisks},
+			responses: map[string]fileResponse{
+				"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.16": {filename: "testdata/discover-releases-stable-4.16-conditional-edges-below-min-target.json", statusCode: 200},
+			},
+			expected: ReleasesByChannel{
+				"stable-4.16": VersionReleases{
+					"4.16.1": Release{
+						Version:             "4.16.1",
+						Channel:             "stable-4.16",
+						Arch:                "amd64",
+						Payload:             "payload-4.16.1",
+						AvailableUpgrades:   []string{"4.16.3"},
+						ConditionalUpgrades: map[string][]Risk{"4.16.3": {{Name: "RiskA"}}},
 					},
 					"4.16.3": Release{
 						Version: "4.16.3",
+						Channel: "stable-4.16",
 						Arch:    "amd64",
 						Payload: "payload-4.16.3",
 					},
@@ -378,46 +706,473 @@ func TestDiscoverReleases(t *testing.T) {
 	}
 }
 
-func TestAggregateReleasesByChannelGroup(t *testing.T) {
-	type testCase struct {
-		name     string
-		input    ReleasesByChannel
-		expected ReleasesByChannel
-	}
-
-	testCases := []testCase{
+func TestDiscoverReleasesStartChannelFormat(t *testing.T) {
+	tests := []struct {
+		startChannel  string
+		expectedError string
+	}{
 		{
-			name: "single channel, no merging",
-			input: ReleasesByChannel{
-				"stable-4.16": VersionReleases{
-					"4.16.1": Release{
-						Version:           "4.16.1",
-						Arch:              "amd64",
-						Payload:           "p1",
-						AvailableUpgrades: []string{"4.16.2"},
-					},
-				},
-			},
-			expected: ReleasesByChannel{
-				"stable": VersionReleases{
-					"4.16.1": Release{
-						Version:           "4.16.1",
-						Arch:              "amd64",
-						Payload:           "p1",
-						AvailableUpgrades: []string{"4.16.2"},
-					},
-				},
-			},
+			startChannel:  "stable",
+			expectedError: `invalid start channel "stable": expected the <prefix>-<version> format, e.g. stable-4.16`,
 		},
 		{
-			name: "two channels with same prefix, merging AvailableUpgrades",
-			input: ReleasesByChannel{
-				"stable-4.16": VersionReleases{
-					"4.16.1": Release{
-						Version:           "4.16.1",
-						Arch:              "amd64",
-						Payload:           "p1",
-						AvailableUpgrades: []string{"4.16.2"},
+			startChannel:  "stable-xyz",
+			expectedError: `invalid start channel "stable-xyz": expected the <prefix>-<version> format, e.g. stable-4.16`,
+		},
+		{
+			startChannel: "stable-4.16",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.startChannel, func(t *testing.T) {
+			hClient := fakeHTTPClientForFiles(t, map[string]string{
+				"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16.json",
+			})
+			_, err := New(hClient).DiscoverReleases(nil, tc.startChannel, "amd64", nil)
+			if tc.expectedError == "" {
+				if err != nil {
+					t.Fatalf("Failed to discover releases: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+			}
+			if err.Error() != tc.expectedError {
+				t.Errorf("Expected error %q, got %q", tc.expectedError, err.Error())
+			}
+		})
+	}
+}
+
+func TestDiscoverReleasesWithGraphs(t *testing.T) {
+	files := map[string]string{
+		"stable-4.16": "testdata/discover-releases-stable-4.16-with-4.17-4.18.json",
+		"stable-4.17": "testdata/discover-releases-stable-4.17.json",
+		"stable-4.18": "testdata/discover-releases-stable-4.18.json",
+	}
+	responses := map[string]string{}
+	expectedGraphs := map[string]*Graph{}
+	for channel, filename := range files {
+		responses["https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel="+channel] = filename
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatalf("Failed to read file %s: %v", filename, err)
+		}
+		graph, err := parseGraph(data, filename, version.NewVersion)
+		if err != nil {
+			t.Fatalf("Failed to parse file %s: %v", filename, err)
+		}
+		expectedGraphs[channel] = graph
+	}
+
+	releases, graphs, err := New(fakeHTTPClientForFiles(t, responses)).DiscoverReleasesWithGraphs(rawURLtoURLOrDie("https://api.openshift.com/api/upgrades_info/graph"), "stable-4.16", "amd64", nil)
+	if err != nil {
+		t.Fatalf("Failed to discover releases: %v", err)
+	}
+	if len(releases) != 3 {
+		t.Errorf("Expected releases for 3 channels, got %d", len(releases))
+	}
+	if !reflect.DeepEqual(expectedGraphs, graphs) {
+		t.Errorf("Expected graphs %+v, got %+v", expectedGraphs, graphs)
+	}
+}
+
+func TestDiscoverReleasesWithUnfetchedChannels(t *testing.T) {
+	hClient := &http.Client{
+		Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+			files := map[string]string{
+				"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-with-4.17-4.18.json",
+				"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.17": "testdata/discover-releases-stable-4.17.json",
+			}
+			filename, ok := files[req.URL.String()]
+			if !ok {
+				return &http.Response{
+					StatusCode: http.StatusNotFound,
+					Body:       ioutil.NopCloser(strings.NewReader("")),
+				}
+			}
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatalf("Failed to read file %s: %v", filename, err)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader(data)),
+			}
+		}),
+	}
+
+	releases, unfetched, err := New(hClient).DiscoverReleasesWithUnfetchedChannels(rawURLtoURLOrDie("https://api.openshift.com/api/upgrades_info/graph"), "stable-4.16", "amd64", nil)
+	if err != nil {
+		t.Fatalf("Failed to discover releases: %v", err)
+	}
+	expectedUnfetched := []UnfetchedChannel{{Channel: "stable-4.18", StatusCode: http.StatusNotFound, Reason: "channel not found"}}
+	if diff := cmp.Diff(expectedUnfetched, unfetched); diff != "" {
+		t.Errorf("Unfetched channels mismatch (-expected +got):\n%s", diff)
+	}
+	if _, ok := releases["stable-4.18"]; ok {
+		t.Errorf("Expected no releases for the unfetched channel stable-4.18")
+	}
+	if _, ok := releases["stable-4.17"]; !ok {
+		t.Errorf("Expected releases for the channel stable-4.17")
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	hClient := &http.Client{
+		Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+			files := map[string]string{
+				"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-with-4.17-4.18.json",
+				"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.17": "testdata/discover-releases-stable-4.17.json",
+			}
+			filename, ok := files[req.URL.String()]
+			if !ok {
+				return &http.Response{
+					StatusCode: http.StatusNotFound,
+					Body:       ioutil.NopCloser(strings.NewReader("")),
+				}
+			}
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatalf("Failed to read file %s: %v", filename, err)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader(data)),
+			}
+		}),
+	}
+
+	result, err := New(hClient).Discover(nil, "stable-4.16", "amd64", nil)
+	if err != nil {
+		t.Fatalf("Failed to discover releases: %v", err)
+	}
+	if diff := cmp.Diff([]string{"stable-4.16", "stable-4.17"}, result.Releases.SortedChannels()); diff != "" {
+		t.Errorf("Channels mismatch (-expected +got):\n%s", diff)
+	}
+	if len(result.ChannelErrors) != 1 {
+		t.Fatalf("Expected exactly one channel error, got %v", result.ChannelErrors)
+	}
+	var statusErr *StatusError
+	if !errors.As(result.ChannelErrors["stable-4.18"], &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected a 404 StatusError for stable-4.18, got %v", result.ChannelErrors["stable-4.18"])
+	}
+	expectedStats := DiscoveryStats{ChannelsFetched: 2, Nodes: 2, ReleasesKept: 2}
+	if diff := cmp.Diff(expectedStats, result.Stats); diff != "" {
+		t.Errorf("Stats mismatch (-expected +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"stable-4.16", "stable-4.17"}, result.DiscoveredOrder); diff != "" {
+		t.Errorf("Discovered order mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestDiscoverReleasesWithStats(t *testing.T) {
+	hClient := fakeHTTPClientForFiles(t, map[string]string{
+		"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-logging.json",
+		"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.17": "testdata/discover-releases-stable-4.16-conditional-edges-below-min-target.json",
+	})
+
+	_, stats, err := New(hClient).DiscoverReleasesWithStats(rawURLtoURLOrDie("https://api.openshift.com/api/upgrades_info/graph"), "stable-4.16", "amd64", nil)
+	if err != nil {
+		t.Fatalf("Failed to discover releases: %v", err)
+	}
+	expected := DiscoveryStats{
+		ChannelsFetched:       2,
+		Nodes:                 5,
+		Edges:                 1,
+		ConditionalEdgeGroups: 1,
+		ReleasesKept:          3,
+	}
+	if diff := cmp.Diff(expected, stats); diff != "" {
+		t.Errorf("Stats mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestDiscoverReleasesWithChannelOrder(t *testing.T) {
+	responses := map[string]string{}
+	for _, channel := range []string{"stable-4.16", "stable-4.17", "stable-4.18", "stable-4.19", "stable-4.20"} {
+		responses["https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel="+channel] = "testdata/discover-releases-" + channel + "-fan-out.json"
+	}
+
+	releases, order, err := New(fakeHTTPClientForFiles(t, responses)).DiscoverReleasesWithChannelOrder(rawURLtoURLOrDie("https://api.openshift.com/api/upgrades_info/graph"), "stable-4.16", "amd64", nil)
+	if err != nil {
+		t.Fatalf("Failed to discover releases: %v", err)
+	}
+	if len(releases) != 5 {
+		t.Errorf("Expected releases for 5 channels, got %d", len(releases))
+	}
+	// stable-4.18 is queued before stable-4.17 and stable-4.20 is discovered before stable-4.19
+	expected := []string{"stable-4.16", "stable-4.17", "stable-4.18", "stable-4.19", "stable-4.20"}
+	if diff := cmp.Diff(expected, order); diff != "" {
+		t.Errorf("Channel order mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestDiscoverReleasesConditionalUpdatesSchema(t *testing.T) {
+	tests := []struct {
+		name         string
+		allowedRisks []string
+	}{
+		{name: "no risks accepted"},
+		{name: "some risks accepted", allowedRisks: []string{"Everyone"}},
+		{name: "all risks accepted", allowedRisks: []string{AllConditionalEdgeRisks}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			discover := func(filename string) ReleasesByChannel {
+				hClient := fakeHTTPClientForFiles(t, map[string]string{
+					"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.16": filename,
+				})
+				releases, err := New(hClient).DiscoverReleases(nil, "stable-4.16", "amd64", tc.allowedRisks)
+				if err != nil {
+					t.Fatalf("Failed to discover releases from %s: %v", filename, err)
+				}
+				return releases
+			}
+
+			expected := discover("testdata/discover-releases-stable-4.16-conditional-edges-matching-rules.json")
+			got := discover("testdata/discover-releases-stable-4.16-conditional-updates.json")
+			if len(expected["stable-4.16"]["4.16.1"].ConditionalUpgrades) != 2 {
+				t.Fatalf("Expected 2 conditional upgrades, got %+v", expected["stable-4.16"]["4.16.1"].ConditionalUpgrades)
+			}
+			if diff := cmp.Diff(expected, got); diff != "" {
+				t.Errorf("Releases mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPlanFetches(t *testing.T) {
+	var requests []*http.Request
+	hClient := fakeHTTPClientRecordingRequests(t, "testdata/discover-releases-stable-4.16-overlapping-channels.json", &requests)
+
+	urls, err := New(hClient).PlanFetches(rawURLtoURLOrDie("https://api.openshift.com/api/upgrades_info/graph"), "stable-4.16", "x86_64")
+	if err != nil {
+		t.Fatalf("PlanFetches returned an error: %v", err)
+	}
+	expected := []string{
+		"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.16",
+		"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.17",
+		"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.18",
+	}
+	if diff := cmp.Diff(expected, urls); diff != "" {
+		t.Errorf("URLs mismatch (-expected +got):\n%s", diff)
+	}
+	if len(requests) != 1 {
+		t.Errorf("Expected only the start channel to be fetched, got %d requests", len(requests))
+	}
+}
+
+func TestDiscoverReleasesDownstreamNotFound(t *testing.T) {
+	responses := map[string]string{
+		"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-with-4.17-4.18.json",
+		"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.17": "testdata/discover-releases-stable-4.17.json",
+	}
+
+	_, err := New(fakeHTTPClientForFilesOr404(t, responses)).DiscoverReleases(nil, "stable-4.16", "amd64", nil)
+	expectedError := "error fetching amd64 graph for channel stable-4.18: error: status 404"
+	if err == nil {
+		t.Fatalf("Expected error containing %q, but got none", expectedError)
+	}
+	if !strings.Contains(err.Error(), expectedError) {
+		t.Errorf("Expected error containing %q, got %q", expectedError, err.Error())
+	}
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected a 404 StatusError, got %v", err)
+	}
+
+	releases, err := New(fakeHTTPClientForFilesOr404(t, responses), WithLenientDownstream()).DiscoverReleases(nil, "stable-4.16", "amd64", nil)
+	if err != nil {
+		t.Fatalf("Failed to discover releases with WithLenientDownstream: %v", err)
+	}
+	if diff := cmp.Diff([]string{"stable-4.16", "stable-4.17"}, releases.SortedChannels()); diff != "" {
+		t.Errorf("Channels mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestDiscoverReleasesFetchesEachURLOnce(t *testing.T) {
+	responses := map[string]string{
+		"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-overlapping-channels.json",
+		"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.17": "testdata/discover-releases-stable-4.17-overlapping-channels.json",
+		"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.18": "testdata/discover-releases-stable-4.18.json",
+	}
+	fetches := map[string]int{}
+	hClient := fakeHTTPClientForFiles(t, responses)
+	transport := hClient.Transport
+	hClient.Transport = RoundTripFunc(func(req *http.Request) *http.Response {
+		fetches[req.URL.String()]++
+		resp, _ := transport.RoundTrip(req)
+		return resp
+	})
+
+	_, err := New(hClient).DiscoverReleases(rawURLtoURLOrDie("https://api.openshift.com/api/upgrades_info/graph"), "stable-4.16", "amd64", nil)
+	if err != nil {
+		t.Fatalf("Failed to discover releases: %v", err)
+	}
+	for u := range responses {
+		if fetches[u] != 1 {
+			t.Errorf("Expected %s to be fetched exactly once, got %d", u, fetches[u])
+		}
+	}
+}
+
+func TestFetchGraphMemoized(t *testing.T) {
+	fetches := 0
+	hClient := fakeHTTPClientForFiles(t, map[string]string{
+		"https://api.openshift.com/api/upgrades_info/graph?arch=amd64&channel=stable-4.16": "testdata/fetch-graph-valid-response.json",
+	})
+	transport := hClient.Transport
+	hClient.Transport = RoundTripFunc(func(req *http.Request) *http.Response {
+		fetches++
+		resp, _ := transport.RoundTrip(req)
+		return resp
+	})
+	target := New(hClient)
+	memo := make(graphMemo)
+
+	for i := 0; i < 2; i++ {
+		graph, err := target.fetchGraphMemoized(context.Background(), rawURLtoURLOrDie("https://api.openshift.com/api/upgrades_info/graph"), "stable-4.16", "amd64", memo)
+		if err != nil {
+			t.Fatalf("fetchGraphMemoized returned an error: %v", err)
+		}
+		if len(graph.Nodes) != 2 {
+			t.Errorf("Expected 2 nodes, got %d", len(graph.Nodes))
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("Expected a single fetch, got %d", fetches)
+	}
+}
+
+func TestSplitChannel(t *testing.T) {
+	tests := []struct {
+		name            string
+		channel         string
+		expectedPrefix  string
+		expectedVersion string
+		expectedError   string
+	}{
+		{
+			name:            "prefix and minor version",
+			channel:         "stable-4.16",
+			expectedPrefix:  "stable-",
+			expectedVersion: "4.16",
+		},
+		{
+			name:            "suffix after the version is dropped",
+			channel:         "candidate-4.16-ec",
+			expectedPrefix:  "candidate-",
+			expectedVersion: "4.16",
+		},
+		{
+			name:          "no parseable version",
+			channel:       "stable-latest",
+			expectedError: "invalid channel format: stable-latest",
+		},
+		{
+			name:          "no hyphen",
+			channel:       "stable",
+			expectedError: "invalid channel format: stable",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			prefix, ver, err := splitChannel(tc.channel)
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if prefix != tc.expectedPrefix || ver != tc.expectedVersion {
+				t.Errorf("Expected prefix %q and version %q, got %q and %q", tc.expectedPrefix, tc.expectedVersion, prefix, ver)
+			}
+		})
+	}
+}
+
+func TestExtractSemVersionFromChannel(t *testing.T) {
+	tests := []struct {
+		channel       string
+		prefix        string
+		expected      string
+		expectedError bool
+	}{
+		{channel: "stable-4.16", prefix: "stable-", expected: "4.16.0"},
+		{channel: "candidate-4.16-ec", prefix: "candidate-", expected: "4.16.0"},
+		{channel: "stable-latest", prefix: "stable-", expectedError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.channel, func(t *testing.T) {
+			v, err := New(nil).extractSemVersionFromChannel(tc.channel, tc.prefix)
+			if tc.expectedError {
+				if err == nil {
+					t.Fatalf("Expected an error, but got %v", v)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if v.String() != tc.expected {
+				t.Errorf("Expected version %s, got %s", tc.expected, v)
+			}
+		})
+	}
+}
+
+func TestAggregateReleasesByChannelGroup(t *testing.T) {
+	type testCase struct {
+		name     string
+		input    ReleasesByChannel
+		expected ReleasesByChannel
+	}
+
+	testCases := []testCase{
+		{
+			name: "single channel, no merging",
+			input: ReleasesByChannel{
+				"stable-4.16": VersionReleases{
+					"4.16.1": Release{
+						Version:           "4.16.1",
+						Arch:              "amd64",
+						Payload:           "p1",
+						AvailableUpgrades: []string{"4.16.2"},
+					},
+				},
+			},
+			expected: ReleasesByChannel{
+				"stable": VersionReleases{
+					"4.16.1": Release{
+						Version:           "4.16.1",
+						Arch:              "amd64",
+						Payload:           "p1",
+						AvailableUpgrades: []string{"4.16.2"},
+					},
+				},
+			},
+		},
+		{
+			name: "two channels with same prefix, merging AvailableUpgrades",
+			input: ReleasesByChannel{
+				"stable-4.16": VersionReleases{
+					"4.16.1": Release{
+						Version:           "4.16.1",
+						Arch:              "amd64",
+						Payload:           "p1",
+						AvailableUpgrades: []string{"4.16.2"},
 					},
 					"4.16.2": Release{
 						Version:           "4.16.2",
@@ -495,6 +1250,156 @@ func TestAggregateReleasesByChannelGroup(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "merge Metadata, keeping existing values and merging channels",
+			input: ReleasesByChannel{
+				"stable-4.16": VersionReleases{
+					"4.16.1": Release{
+						Version: "4.16.1",
+						Arch:    "amd64",
+						Payload: "p1",
+						Metadata: map[string]string{
+							"io.openshift.upgrades.graph.release.channels": "stable-4.16,fast-4.16",
+							"io.openshift.upgrades.graph.release.url":      "https://example.com/4.16.1",
+						},
+					},
+				},
+				"stable-4.17": VersionReleases{
+					"4.16.1": Release{
+						Version: "4.16.1",
+						Arch:    "amd64",
+						Payload: "p1",
+						Metadata: map[string]string{
+							"io.openshift.upgrades.graph.release.channels": "stable-4.16,stable-4.17",
+							"io.openshift.upgrades.graph.release.url":      "https://example.com/4.16.1",
+							"extra": "value",
+						},
+					},
+				},
+			},
+			expected: ReleasesByChannel{
+				"stable": VersionReleases{
+					"4.16.1": Release{
+						Version: "4.16.1",
+						Arch:    "amd64",
+						Payload: "p1",
+						Metadata: map[string]string{
+							"io.openshift.upgrades.graph.release.channels": "stable-4.16,fast-4.16,stable-4.17",
+							"io.openshift.upgrades.graph.release.url":      "https://example.com/4.16.1",
+							"extra": "value",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "keep the first seen originating channel",
+			input: ReleasesByChannel{
+				"stable-4.16": VersionReleases{
+					"4.16.1": Release{
+						Version:           "4.16.1",
+						Channel:           "stable-4.16",
+						Arch:              "amd64",
+						Payload:           "p1",
+						AvailableUpgrades: []string{"4.16.2"},
+					},
+				},
+				"stable-4.17": VersionReleases{
+					"4.16.1": Release{
+						Version:           "4.16.1",
+						Channel:           "stable-4.17",
+						Arch:              "amd64",
+						Payload:           "p1",
+						AvailableUpgrades: []string{"4.17.1"},
+					},
+					"4.17.1": Release{
+						Version: "4.17.1",
+						Channel: "stable-4.17",
+						Arch:    "amd64",
+						Payload: "p2",
+					},
+				},
+			},
+			expected: ReleasesByChannel{
+				"stable": VersionReleases{
+					"4.16.1": Release{
+						Version:           "4.16.1",
+						Channel:           "stable-4.16",
+						Arch:              "amd64",
+						Payload:           "p1",
+						AvailableUpgrades: []string{"4.16.2", "4.17.1"},
+					},
+					"4.17.1": Release{
+						Version: "4.17.1",
+						Channel: "stable-4.17",
+						Arch:    "amd64",
+						Payload: "p2",
+					},
+				},
+			},
+		},
+		{
+			name: "merged AvailableUpgrades are sorted in ascending semantic-version order",
+			input: ReleasesByChannel{
+				"stable-4.16": VersionReleases{
+					"4.16.1": Release{
+						Version:           "4.16.1",
+						Arch:              "amd64",
+						Payload:           "p1",
+						AvailableUpgrades: []string{"4.16.10", "4.16.2"},
+					},
+				},
+				"stable-4.17": VersionReleases{
+					"4.16.1": Release{
+						Version:           "4.16.1",
+						Arch:              "amd64",
+						Payload:           "p1",
+						AvailableUpgrades: []string{"4.17.0", "4.16.9"},
+					},
+				},
+			},
+			expected: ReleasesByChannel{
+				"stable": VersionReleases{
+					"4.16.1": Release{
+						Version:           "4.16.1",
+						Arch:              "amd64",
+						Payload:           "p1",
+						AvailableUpgrades: []string{"4.16.2", "4.16.9", "4.16.10", "4.17.0"},
+					},
+				},
+			},
+		},
+		{
+			name: "merge ConditionalUpgrades without duplications",
+			input: ReleasesByChannel{
+				"stable-4.16": VersionReleases{
+					"4.16.1": Release{
+						Version:             "4.16.1",
+						Arch:                "amd64",
+						Payload:             "p1",
+						ConditionalUpgrades: map[string][]Risk{"4.16.3": {{Name: "RiskA"}}},
+					},
+				},
+				"stable-4.17": VersionReleases{
+					"4.16.1": Release{
+						Version:             "4.16.1",
+						Arch:                "amd64",
+						Payload:             "p1",
+						ConditionalUpgrades: map[string][]Risk{"4.16.3": {{Name: "RiskA"}, {Name: "RiskB"}}, "4.17.1": {{Name: "RiskC"}}},
+					},
+				},
+			},
+			expected: ReleasesByChannel{
+				"stable": VersionReleases{
+					"4.16.1": Release{
+						Version:             "4.16.1",
+						Arch:                "amd64",
+						Payload:             "p1",
+						ConditionalUpgrades: map[string][]Risk{"4.16.3": {{Name: "RiskA"}, {Name: "RiskB"}}, "4.17.1": {{Name: "RiskC"}}},
+					},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -511,6 +1416,406 @@ func TestAggregateReleasesByChannelGroup(t *testing.T) {
 	}
 }
 
+func TestAggregateBy(t *testing.T) {
+	input := ReleasesByChannel{
+		"eus-4.16": VersionReleases{
+			"4.16.2": Release{Version: "4.16.2", Payload: "p2", AvailableUpgrades: []string{"4.16.3"}},
+		},
+		"stable-4.16": VersionReleases{
+			"4.16.2": Release{Version: "4.16.2", Payload: "p2", AvailableUpgrades: []string{"4.17.1"}},
+		},
+		"stable-4.17": VersionReleases{
+			"4.17.1": Release{Version: "4.17.1", Payload: "p3"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		grouper  func(channel string) string
+		expected ReleasesByChannel
+	}{
+		{
+			name: "group by minor version",
+			grouper: func(channel string) string {
+				_, minor, _ := strings.Cut(channel, "-")
+				return minor
+			},
+			expected: ReleasesByChannel{
+				"4.16": VersionReleases{
+					"4.16.2": Release{Version: "4.16.2", Payload: "p2", AvailableUpgrades: []string{"4.16.3", "4.17.1"}},
+				},
+				"4.17": VersionReleases{
+					"4.17.1": Release{Version: "4.17.1", Payload: "p3"},
+				},
+			},
+		},
+		{
+			name: "remap eus into the stable group",
+			grouper: func(channel string) string {
+				if group := ChannelPrefixGroup(channel); group != "eus" {
+					return group
+				}
+				return "stable"
+			},
+			expected: ReleasesByChannel{
+				"stable": VersionReleases{
+					"4.16.2": Release{Version: "4.16.2", Payload: "p2", AvailableUpgrades: []string{"4.16.3", "4.17.1"}},
+					"4.17.1": Release{Version: "4.17.1", Payload: "p3"},
+				},
+			},
+		},
+		{
+			name:    "default grouper groups by prefix",
+			grouper: ChannelPrefixGroup,
+			expected: ReleasesByChannel{
+				"eus": VersionReleases{
+					"4.16.2": Release{Version: "4.16.2", Payload: "p2", AvailableUpgrades: []string{"4.16.3"}},
+				},
+				"stable": VersionReleases{
+					"4.16.2": Release{Version: "4.16.2", Payload: "p2", AvailableUpgrades: []string{"4.17.1"}},
+					"4.17.1": Release{Version: "4.17.1", Payload: "p3"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := AggregateBy(input, tc.grouper)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Errorf("Unexpected output (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAggregateReleasesByMinor(t *testing.T) {
+	input := ReleasesByChannel{
+		"fast-4.16": VersionReleases{
+			"4.16.2": Release{Version: "4.16.2", Channel: "fast-4.16", Payload: "p2", AvailableUpgrades: []string{"4.16.5", "4.16.3"}},
+			"4.16.5": Release{Version: "4.16.5", Channel: "fast-4.16", Payload: "p5"},
+		},
+		"stable-4.16": VersionReleases{
+			"4.16.2": Release{Version: "4.16.2", Channel: "stable-4.16", Payload: "p2", AvailableUpgrades: []string{"4.16.3"}},
+			"4.16.3": Release{Version: "4.16.3", Channel: "stable-4.16", Payload: "p3"},
+		},
+		"stable-4.17": VersionReleases{
+			"4.17.1": Release{Version: "4.17.1", Channel: "stable-4.17", Payload: "p4"},
+		},
+	}
+	expected := ReleasesByChannel{
+		"4.16": VersionReleases{
+			"4.16.2": Release{Version: "4.16.2", Channel: "fast-4.16", Payload: "p2", AvailableUpgrades: []string{"4.16.3", "4.16.5"}},
+			"4.16.3": Release{Version: "4.16.3", Channel: "stable-4.16", Payload: "p3"},
+			"4.16.5": Release{Version: "4.16.5", Channel: "fast-4.16", Payload: "p5"},
+		},
+		"4.17": VersionReleases{
+			"4.17.1": Release{Version: "4.17.1", Channel: "stable-4.17", Payload: "p4"},
+		},
+	}
+
+	result, err := AggregateReleasesByMinor(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(expected, result); diff != "" {
+		t.Errorf("Unexpected output (-expected +got):\n%s", diff)
+	}
+}
+
+func TestChannelMinorGroup(t *testing.T) {
+	tests := []struct {
+		channel  string
+		expected string
+	}{
+		{channel: "stable-4.16", expected: "4.16"},
+		{channel: "eus-4.16.2", expected: "4.16"},
+		{channel: "candidate-4.17-ec", expected: "4.17"},
+		{channel: "fast-4.16.x", expected: "4.16"},
+		{channel: "eus-foo-4.16", expected: "eus-foo-4.16"},
+		{channel: "stable", expected: "stable"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.channel, func(t *testing.T) {
+			if got := channelMinorGroup(tc.channel); got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestSortVersions(t *testing.T) {
+	versions := []string{"4.16.10", "zzz", "4.16.0", "not-a-version", "4.16.9", "4.16", "v4.16.1"}
+
+	invalid := SortVersions(versions)
+
+	expected := []string{"4.16", "4.16.0", "v4.16.1", "4.16.9", "4.16.10", "not-a-version", "zzz"}
+	if diff := cmp.Diff(expected, versions); diff != "" {
+		t.Errorf("Versions mismatch (-expected +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"not-a-version", "zzz"}, invalid); diff != "" {
+		t.Errorf("Invalid versions mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestAggregateByWithPayloadConflicts(t *testing.T) {
+	input := ReleasesByChannel{
+		"candidate-4.16": VersionReleases{
+			"4.16.2": Release{Version: "4.16.2", Payload: "payload-c"},
+		},
+		"fast-4.16": VersionReleases{
+			"4.16.2": Release{Version: "4.16.2", Payload: "payload-b"},
+			"4.16.3": Release{Version: "4.16.3", Payload: "payload-3"},
+		},
+		"stable-4.16": VersionReleases{
+			"4.16.2": Release{Version: "4.16.2", Payload: "payload-a"},
+			"4.16.3": Release{Version: "4.16.3", Payload: "payload-3"},
+		},
+	}
+
+	result, conflicts, err := AggregateByWithPayloadConflicts(input, channelMinorGroup)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedConflicts := map[string][]string{"4.16.2": {"payload-a", "payload-b", "payload-c"}}
+	if diff := cmp.Diff(expectedConflicts, conflicts); diff != "" {
+		t.Errorf("Conflicts mismatch (-expected +got):\n%s", diff)
+	}
+	if got := result["4.16"]["4.16.2"].Payload; got != "payload-c" {
+		t.Errorf("Expected the payload of the first channel %q to be kept, got %q", "payload-c", got)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		dst      ReleasesByChannel
+		src      ReleasesByChannel
+		expected ReleasesByChannel
+	}{
+		{
+			name: "disjoint channels",
+			dst: ReleasesByChannel{
+				"stable-4.16": {"4.16.1": {Version: "4.16.1", Payload: "p-4.16.1"}},
+			},
+			src: ReleasesByChannel{
+				"stable-4.17": {"4.17.0": {Version: "4.17.0", Payload: "p-4.17.0"}},
+			},
+			expected: ReleasesByChannel{
+				"stable-4.16": {"4.16.1": {Version: "4.16.1", Payload: "p-4.16.1"}},
+				"stable-4.17": {"4.17.0": {Version: "4.17.0", Payload: "p-4.17.0"}},
+			},
+		},
+		{
+			name: "overlapping channels",
+			dst: ReleasesByChannel{
+				"stable-4.16": {"4.16.1": {Version: "4.16.1", Payload: "p-4.16.1"}},
+			},
+			src: ReleasesByChannel{
+				"stable-4.16": {"4.16.2": {Version: "4.16.2", Payload: "p-4.16.2"}},
+			},
+			expected: ReleasesByChannel{
+				"stable-4.16": {
+					"4.16.1": {Version: "4.16.1", Payload: "p-4.16.1"},
+					"4.16.2": {Version: "4.16.2", Payload: "p-4.16.2"},
+				},
+			},
+		},
+		{
+			name: "overlapping versions with extra upgrades",
+			dst: ReleasesByChannel{
+				"stable-4.16": {
+					"4.16.1": {
+						Version:           "4.16.1",
+						Payload:           "p-4.16.1",
+						AvailableUpgrades: []string{"4.16.3"},
+						Metadata:          map[string]string{releaseChannelsMetadataKey: "stable-4.16", "url": "dst"},
+					},
+				},
+			},
+			src: ReleasesByChannel{
+				"stable-4.16": {
+					"4.16.1": {
+						Version:             "4.16.1",
+						Payload:             "p-4.16.1",
+						AvailableUpgrades:   []string{"4.16.2", "4.16.3"},
+						ConditionalUpgrades: map[string][]Risk{"4.16.4": {{Name: "RiskA"}}},
+						Metadata:            map[string]string{releaseChannelsMetadataKey: "fast-4.16", "url": "src"},
+					},
+				},
+			},
+			expected: ReleasesByChannel{
+				"stable-4.16": {
+					"4.16.1": {
+						Version:             "4.16.1",
+						Payload:             "p-4.16.1",
+						AvailableUpgrades:   []string{"4.16.3", "4.16.2"},
+						ConditionalUpgrades: map[string][]Risk{"4.16.4": {{Name: "RiskA"}}},
+						Metadata:            map[string]string{releaseChannelsMetadataKey: "stable-4.16,fast-4.16", "url": "dst"},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dstBefore := fmt.Sprintf("%+v", tc.dst)
+			if diff := cmp.Diff(tc.expected, Merge(tc.dst, tc.src)); diff != "" {
+				t.Errorf("Merged releases mismatch (-expected +got):\n%s", diff)
+			}
+			if dstAfter := fmt.Sprintf("%+v", tc.dst); dstAfter != dstBefore {
+				t.Errorf("Expected dst to be left unmodified, got %s", dstAfter)
+			}
+		})
+	}
+}
+
+func TestMergeWithPayloadConflicts(t *testing.T) {
+	dst := ReleasesByChannel{
+		"stable-4.16": {"4.16.2": {Version: "4.16.2", Payload: "payload-a"}},
+	}
+	src := ReleasesByChannel{
+		"stable-4.16": {"4.16.2": {Version: "4.16.2", Payload: "payload-b"}},
+		"fast-4.16":   {"4.16.2": {Version: "4.16.2", Payload: "payload-c"}},
+	}
+
+	merged, conflicts := MergeWithPayloadConflicts(dst, src)
+	expectedConflicts := map[string][]string{"4.16.2": {"payload-a", "payload-b"}}
+	if diff := cmp.Diff(expectedConflicts, conflicts); diff != "" {
+		t.Errorf("Conflicts mismatch (-expected +got):\n%s", diff)
+	}
+	if got := merged["stable-4.16"]["4.16.2"].Payload; got != "payload-a" {
+		t.Errorf("Expected the payload of dst %q to be kept, got %q", "payload-a", got)
+	}
+}
+
+func TestProcessEdgesSortsUpgrades(t *testing.T) {
+	nodes := []Node{
+		{Version: versionOrDie("4.16.1")},
+		{Version: versionOrDie("4.16.2")},
+		{Version: versionOrDie("4.16.9")},
+		{Version: versionOrDie("4.16.10")},
+	}
+	edges := [][]int{{0, 1}, {0, 2}, {0, 3}, {1, 2}, {1, 3}}
+	reversed := slices.Clone(edges)
+	slices.Reverse(reversed)
+
+	for name, graphEdges := range map[string][][]int{"edges in order": edges, "edges in reverse order": reversed} {
+		t.Run(name, func(t *testing.T) {
+			releases := VersionReleases{
+				"4.16.1":  Release{Version: "4.16.1"},
+				"4.16.2":  Release{Version: "4.16.2"},
+				"4.16.9":  Release{Version: "4.16.9"},
+				"4.16.10": Release{Version: "4.16.10"},
+			}
+
+			if err := New(nil).processEdges(&Graph{Nodes: nodes, Edges: graphEdges}, releases); err != nil {
+				t.Fatalf("processEdges returned an error: %v", err)
+			}
+
+			if diff := cmp.Diff([]string{"4.16.2", "4.16.9", "4.16.10"}, releases["4.16.1"].AvailableUpgrades); diff != "" {
+				t.Errorf("AvailableUpgrades of 4.16.1 mismatch (-expected +got):\n%s", diff)
+			}
+			if diff := cmp.Diff([]string{"4.16.9", "4.16.10"}, releases["4.16.2"].AvailableUpgrades); diff != "" {
+				t.Errorf("AvailableUpgrades of 4.16.2 mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProcessConditionalEdges(t *testing.T) {
+	conditionalEdges := []ConditionalEdges{
+		{
+			Edges: []ConditionalEdge{{From: "4.16.1", To: "4.16.3"}},
+			Risks: []Risk{{Name: "RiskA"}, {Name: "SomeUnknownRisk"}},
+		},
+	}
+
+	tests := []struct {
+		name                        string
+		allowedConditionalEdgeRisks []string
+		expectedUpgrades            []string
+	}{
+		{
+			name:                        "unknown risk rejects the edge but records it as conditional",
+			allowedConditionalEdgeRisks: []string{"RiskA"},
+		},
+		{
+			name:                        "all-risks mode applies the edge with an unknown risk",
+			allowedConditionalEdgeRisks: []string{AllConditionalEdgeRisks},
+			expectedUpgrades:            []string{"4.16.3"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			releases := VersionReleases{
+				"4.16.1": Release{Version: "4.16.1"},
+				"4.16.3": Release{Version: "4.16.3"},
+			}
+
+			New(nil).processConditionalEdges(conditionalEdges, AllowListRiskEvaluator(tc.allowedConditionalEdgeRisks), releases)
+
+			if diff := cmp.Diff(tc.expectedUpgrades, releases["4.16.1"].AvailableUpgrades); diff != "" {
+				t.Errorf("AvailableUpgrades mismatch (-expected +got):\n%s", diff)
+			}
+			expectedConditionalUpgrades := map[string][]Risk{"4.16.3": {{Name: "RiskA"}, {Name: "SomeUnknownRisk"}}}
+			if diff := cmp.Diff(expectedConditionalUpgrades, releases["4.16.1"].ConditionalUpgrades); diff != "" {
+				t.Errorf("ConditionalUpgrades mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// fakeHTTPClientForFiles returns an http.Client that serves the given
+// testdata files (URL -> filename) with a 200 status code.
+func fakeHTTPClientForFiles(t *testing.T, responses map[string]string) *http.Client {
+	return &http.Client{
+		Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+			filename, ok := responses[req.URL.String()]
+			if !ok {
+				t.Fatalf("No response mapping for URL: %s", req.URL.String())
+			}
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatalf("Failed to read file %s: %v", filename, err)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader(data)),
+			}
+		}),
+	}
+}
+
+// fakeHTTPClientForFilesOr404 is like fakeHTTPClientForFiles but responds with 404 Not Found to the unmapped URLs.
+func fakeHTTPClientForFilesOr404(t *testing.T, responses map[string]string) *http.Client {
+	return &http.Client{
+		Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+			filename, ok := responses[req.URL.String()]
+			if !ok {
+				return &http.Response{
+					StatusCode: http.StatusNotFound,
+					Body:       ioutil.NopCloser(strings.NewReader("")),
+				}
+			}
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatalf("Failed to read file %s: %v", filename, err)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader(data)),
+			}
+		}),
+	}
+}
+
 func rawURLtoURLOrDie(rawURL string) *url.URL {
 	u, err := url.Parse(rawURL)
 	if err != nil {
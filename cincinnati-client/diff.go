@@ -0,0 +1,116 @@
+package cincinnaticlient
+
+import (
+	"maps"
+	"slices"
+)
+
+// ReleaseDiff describes the changes between two ReleasesByChannel snapshots.
+// Channels without any change are omitted.
+type ReleaseDiff struct {
+	Channels map[string]ChannelDiff
+}
+
+// ChannelDiff describes the changes of a single channel.
+// The versions are sorted in ascending semantic-version order.
+type ChannelDiff struct {
+	// Added holds the versions present only in the new snapshot.
+	Added []string
+	// Removed holds the versions present only in the old snapshot.
+	Removed []string
+	// Changed maps the versions present in both snapshots to the changes of their AvailableUpgrades.
+	Changed map[string]UpgradesDiff
+}
+
+// UpgradesDiff describes the changes of the AvailableUpgrades of a single release.
+// The versions are sorted in ascending semantic-version order.
+type UpgradesDiff struct {
+	Added   []string
+	Removed []string
+}
+
+// Diff compares two ReleasesByChannel snapshots, e.g. taken by two runs of the discovery.
+// The order of the AvailableUpgrades is ignored.
+func Diff(old, new ReleasesByChannel) ReleaseDiff {
+	diff := ReleaseDiff{Channels: make(map[string]ChannelDiff)}
+	channels := slices.Collect(maps.Keys(old))
+	for channel := range new {
+		if _, ok := old[channel]; !ok {
+			channels = append(channels, channel)
+		}
+	}
+	for _, channel := range channels {
+		if channelDiff, changed := diffChannel(old[channel], new[channel]); changed {
+			diff.Channels[channel] = channelDiff
+		}
+	}
+	return diff
+}
+
+// diffChannel compares the releases of a single channel.
+// It returns false if they don't differ.
+func diffChannel(old, new VersionReleases) (ChannelDiff, bool) {
+	var diff ChannelDiff
+	for ver := range new {
+		if _, ok := old[ver]; !ok {
+			diff.Added = append(diff.Added, ver)
+		}
+	}
+	for ver, oldRelease := range old {
+		newRelease, ok := new[ver]
+		if !ok {
+			diff.Removed = append(diff.Removed, ver)
+			continue
+		}
+		added := difference(newRelease.AvailableUpgrades, oldRelease.AvailableUpgrades)
+		removed := difference(oldRelease.AvailableUpgrades, newRelease.AvailableUpgrades)
+		if len(added) == 0 && len(removed) == 0 {
+			continue
+		}
+		if diff.Changed == nil {
+			diff.Changed = make(map[string]UpgradesDiff)
+		}
+		diff.Changed[ver] = UpgradesDiff{Added: added, Removed: removed}
+	}
+	SortVersions(diff.Added)
+	SortVersions(diff.Removed)
+	return diff, len(diff.Added) > 0 || len(diff.Removed) > 0 || len(diff.Changed) > 0
+}
+
+// difference returns the versions of a missing from b, sorted in ascending semantic-version order.
+func difference(a, b []string) []string {
+	var result []string
+	for _, v := range a {
+		if !slices.Contains(b, v) && !slices.Contains(result, v) {
+			result = append(result, v)
+		}
+	}
+	SortVersions(result)
+	return result
+}
+
+// UpgradeEdgeDiffByArch compares the discoveries of two architectures, e.g. amd64 and arm64,
+// and returns, keyed by version, the AvailableUpgrades present in a but missing from b,
+// sorted in ascending semantic-version order. The upgrades of a version are collected
+// from all its channels. Versions without missing upgrades are omitted.
+func UpgradeEdgeDiffByArch(a, b ReleasesByChannel) map[string][]string {
+	bUpgrades := upgradesByVersion(b)
+	diff := make(map[string][]string)
+	for ver, upgrades := range upgradesByVersion(a) {
+		if missing := difference(upgrades, bUpgrades[ver]); len(missing) > 0 {
+			diff[ver] = missing
+		}
+	}
+	return diff
+}
+
+// upgradesByVersion returns the AvailableUpgrades of every version across all channels.
+func upgradesByVersion(releasesByChannel ReleasesByChannel) map[string][]string {
+	upgrades := make(map[string][]string)
+	for _, releases := range releasesByChannel {
+		for ver, release := range releases {
+			upgrades[ver] = append(upgrades[ver], release.AvailableUpgrades...)
+		}
+	}
+	return upgrades
+}
@@ -0,0 +1,114 @@
+package cincinnaticlient
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      ReleasesByChannel
+		new      ReleasesByChannel
+		expected ReleaseDiff
+	}{
+		{
+			name: "added version",
+			old: ReleasesByChannel{
+				"stable-4.16": VersionReleases{"4.16.1": Release{Version: "4.16.1"}},
+			},
+			new: ReleasesByChannel{
+				"stable-4.16": VersionReleases{"4.16.1": Release{Version: "4.16.1"}, "4.16.10": Release{Version: "4.16.10"}, "4.16.2": Release{Version: "4.16.2"}},
+			},
+			expected: ReleaseDiff{Channels: map[string]ChannelDiff{
+				"stable-4.16": {Added: []string{"4.16.2", "4.16.10"}},
+			}},
+		},
+		{
+			name: "removed version and channel",
+			old: ReleasesByChannel{
+				"stable-4.16": VersionReleases{"4.16.1": Release{Version: "4.16.1"}, "4.16.2": Release{Version: "4.16.2"}},
+				"stable-4.17": VersionReleases{"4.17.0": Release{Version: "4.17.0"}},
+			},
+			new: ReleasesByChannel{
+				"stable-4.16": VersionReleases{"4.16.1": Release{Version: "4.16.1"}},
+			},
+			expected: ReleaseDiff{Channels: map[string]ChannelDiff{
+				"stable-4.16": {Removed: []string{"4.16.2"}},
+				"stable-4.17": {Removed: []string{"4.17.0"}},
+			}},
+		},
+		{
+			name: "edge-set change on an existing version ignores ordering",
+			old: ReleasesByChannel{
+				"stable-4.16": VersionReleases{
+					"4.16.1": Release{Version: "4.16.1", AvailableUpgrades: []string{"4.16.3", "4.16.2"}},
+					"4.16.2": Release{Version: "4.16.2", AvailableUpgrades: []string{"4.16.3", "4.16.4"}},
+				},
+			},
+			new: ReleasesByChannel{
+				"stable-4.16": VersionReleases{
+					"4.16.1": Release{Version: "4.16.1", AvailableUpgrades: []string{"4.16.4", "4.16.2"}},
+					"4.16.2": Release{Version: "4.16.2", AvailableUpgrades: []string{"4.16.4", "4.16.3"}},
+				},
+			},
+			expected: ReleaseDiff{Channels: map[string]ChannelDiff{
+				"stable-4.16": {Changed: map[string]UpgradesDiff{
+					"4.16.1": {Added: []string{"4.16.4"}, Removed: []string{"4.16.3"}},
+				}},
+			}},
+		},
+		{
+			name: "identical snapshots",
+			old: ReleasesByChannel{
+				"stable-4.16": VersionReleases{"4.16.1": Release{Version: "4.16.1", AvailableUpgrades: []string{"4.16.2"}}},
+			},
+			new: ReleasesByChannel{
+				"stable-4.16": VersionReleases{"4.16.1": Release{Version: "4.16.1", AvailableUpgrades: []string{"4.16.2"}}},
+			},
+			expected: ReleaseDiff{Channels: map[string]ChannelDiff{}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, Diff(tc.old, tc.new)); diff != "" {
+				t.Errorf("Diff mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUpgradeEdgeDiffByArch(t *testing.T) {
+	amd64 := ReleasesByChannel{
+		"stable-4.16": VersionReleases{
+			"4.16.1": Release{Version: "4.16.1", Arch: "amd64", AvailableUpgrades: []string{"4.16.2", "4.16.3"}},
+			"4.16.2": Release{Version: "4.16.2", Arch: "amd64", AvailableUpgrades: []string{"4.16.3"}},
+			"4.16.3": Release{Version: "4.16.3", Arch: "amd64"},
+		},
+		"stable-4.17": VersionReleases{
+			"4.16.3": Release{Version: "4.16.3", Arch: "amd64", AvailableUpgrades: []string{"4.17.0"}},
+			"4.17.0": Release{Version: "4.17.0", Arch: "amd64"},
+		},
+	}
+	arm64 := ReleasesByChannel{
+		"stable-4.16": VersionReleases{
+			"4.16.1": Release{Version: "4.16.1", Arch: "arm64", AvailableUpgrades: []string{"4.16.3", "4.16.2"}},
+			"4.16.2": Release{Version: "4.16.2", Arch: "arm64"},
+			"4.16.3": Release{Version: "4.16.3", Arch: "arm64"},
+		},
+		"stable-4.17": VersionReleases{
+			"4.16.3": Release{Version: "4.16.3", Arch: "arm64", AvailableUpgrades: []string{"4.17.0"}},
+			"4.17.0": Release{Version: "4.17.0", Arch: "arm64"},
+		},
+	}
+
+	expected := map[string][]string{"4.16.2": {"4.16.3"}}
+	if diff := cmp.Diff(expected, UpgradeEdgeDiffByArch(amd64, arm64)); diff != "" {
+		t.Errorf("Upgrade edge diff mismatch (-expected +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string][]string{}, UpgradeEdgeDiffByArch(arm64, amd64)); diff != "" {
+		t.Errorf("Reversed upgrade edge diff mismatch (-expected +got):\n%s", diff)
+	}
+}
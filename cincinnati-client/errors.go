@@ -0,0 +1,61 @@
+package cincinnaticlient
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrChannelNotFound reports that there is no graph for a channel, e.g. because
+// DiscoverReleasesFromFiles wasn't given a file for it. Discovery skips such channels,
+// unless it is the start channel. Unlike ErrChannelNotFound, a 404 Not Found from the server
+// only makes DiscoverReleasesWithUnfetchedChannels and Discover skip the channel.
+var ErrChannelNotFound = errors.New("channel not found")
+
+// StatusError is returned when the server responds to a graph request
+// with an unexpected status code.
+type StatusError struct {
+	StatusCode int
+	URL        string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("error: status %d when fetching data from %s", e.StatusCode, e.URL)
+}
+
+// AuthError is returned by Ping when the server rejects the request
+// with 401 Unauthorized or 403 Forbidden, e.g. because of a missing or invalid token.
+type AuthError struct {
+	StatusCode int
+	URL        string
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("error: authentication failed with status %d when fetching data from %s", e.StatusCode, e.URL)
+}
+
+// NetworkError is returned by Ping when the request couldn't be sent or the response
+// couldn't be received, e.g. because the host can't be resolved or the connection was refused.
+// Err holds the error reported by the http.Client.
+type NetworkError struct {
+	URL string
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("error fetching data from %s: %v", e.URL, e.Err)
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// EUSPathError is returned by PlanEUSPath when there is no upgrade path
+// between the versions that honors the EUS rules.
+type EUSPathError struct {
+	From string
+	To   string
+}
+
+func (e *EUSPathError) Error() string {
+	return fmt.Sprintf("no EUS upgrade path from %s to %s", e.From, e.To)
+}
@@ -0,0 +1,43 @@
+package cincinnaticlient
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// DiscoverReleasesFromFiles is like DiscoverReleases but reads the graph of every channel
+// from a previously saved graph JSON file instead of fetching it, e.g. for offline analysis.
+// files maps the channel names to the paths of their graph files. The discovered channels
+// without a file are skipped and the startChannel must have one.
+func (c *Client) DiscoverReleasesFromFiles(files map[string]string, startChannel string, arch string, allowedConditionalEdgeRisks []string) (ReleasesByChannel, error) {
+	start, err := c.prepareWalk(startChannel, arch, c.maxVersion)
+	if err != nil {
+		return nil, err
+	}
+	d, err := c.walk(start, startChannel, allowedConditionalEdgeRisks, &fileGraphSource{client: c, files: files})
+	if err != nil {
+		return nil, err
+	}
+	return d.releases, nil
+}
+
+// fileGraphSource is the GraphSource reading the graphs from the files, keyed by channel.
+// The graph files are expected to hold the graphs of the requested arch.
+type fileGraphSource struct {
+	client *Client
+	files  map[string]string
+}
+
+// Fetch reads and parses the graph file of the given channel.
+func (s *fileGraphSource) Fetch(_ context.Context, channel, _ string) (*Graph, error) {
+	filename, ok := s.files[channel]
+	if !ok {
+		return nil, fmt.Errorf("no graph file for channel %s: %w", channel, ErrChannelNotFound)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading graph file %s: %w", filename, err)
+	}
+	return parseGraph(data, filename, s.client.versionParser)
+}
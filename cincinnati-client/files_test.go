@@ -0,0 +1,92 @@
+package cincinnaticlient
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiscoverReleasesFromFiles(t *testing.T) {
+	files := map[string]string{
+		"stable-4.16": "testdata/discover-releases-stable-4.16-with-4.17-4.18.json",
+		"stable-4.17": "testdata/discover-releases-stable-4.17.json",
+		"stable-4.18": "testdata/discover-releases-stable-4.18.json",
+	}
+	responses := map[string]string{}
+	for channel, filename := range files {
+		responses[testGraphURL+"?arch=amd64&channel="+channel] = filename
+	}
+	expected, err := New(fakeHTTPClientForFiles(t, responses)).DiscoverReleases(nil, "stable-4.16", "amd64", nil)
+	if err != nil {
+		t.Fatalf("Failed to discover releases over HTTP: %v", err)
+	}
+
+	hClient := &http.Client{
+		Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+			t.Fatalf("Unexpected request to %s", req.URL)
+			return nil
+		}),
+	}
+	got, err := New(hClient).DiscoverReleasesFromFiles(files, "stable-4.16", "amd64", nil)
+	if err != nil {
+		t.Fatalf("Failed to discover releases from files: %v", err)
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("Releases mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestDiscoverReleasesFromFilesMissingChannels(t *testing.T) {
+	tests := []struct {
+		name             string
+		files            map[string]string
+		expectedChannels []string
+		expectedError    string
+	}{
+		{
+			name: "discovered channel without a file",
+			files: map[string]string{
+				"stable-4.16": "testdata/discover-releases-stable-4.16-with-4.17-4.18.json",
+				"stable-4.18": "testdata/discover-releases-stable-4.18.json",
+			},
+			expectedChannels: []string{"stable-4.16", "stable-4.18"},
+		},
+		{
+			name: "start channel without a file",
+			files: map[string]string{
+				"stable-4.17": "testdata/discover-releases-stable-4.17.json",
+			},
+			expectedError: "no graph file for channel stable-4.16: channel not found",
+		},
+		{
+			name: "missing file",
+			files: map[string]string{
+				"stable-4.16": "testdata/does-not-exist.json",
+			},
+			expectedError: "error reading graph file testdata/does-not-exist.json",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			releases, err := New(nil).DiscoverReleasesFromFiles(tc.files, "stable-4.16", "amd64", nil)
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to discover releases from files: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedChannels, releases.SortedChannels()); diff != "" {
+				t.Errorf("Channels mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
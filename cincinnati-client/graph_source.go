@@ -0,0 +1,29 @@
+package cincinnaticlient
+
+import (
+	"context"
+	"net/url"
+)
+
+// GraphSource provides the graphs of the channels walked by a discovery,
+// e.g. to back it with an embedded FS or a test double instead of the graph API.
+//
+// Fetch returns the graph of the given channel and architecture. The architecture
+// is already normalized with NormalizeArch. An error wrapping ErrChannelNotFound
+// makes discovery skip the channel, unless it is the start channel.
+type GraphSource interface {
+	Fetch(ctx context.Context, channel, arch string) (*Graph, error)
+}
+
+// httpGraphSource is the default GraphSource, fetching the graphs from the graph API.
+// A graph URL is fetched at most once.
+type httpGraphSource struct {
+	client   *Client
+	graphURL *url.URL
+	memo     graphMemo
+}
+
+// Fetch fetches the graph of the given channel and architecture from the graph URL.
+func (s *httpGraphSource) Fetch(ctx context.Context, channel, arch string) (*Graph, error) {
+	return s.client.fetchGraphMemoized(ctx, s.graphURL, channel, arch, s.memo)
+}
@@ -0,0 +1,68 @@
+package cincinnaticlient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// mapGraphSource is a GraphSource serving the graphs keyed by channel and arch, e.g. "stable-4.16/amd64".
+type mapGraphSource map[string]*Graph
+
+func (s mapGraphSource) Fetch(_ context.Context, channel, arch string) (*Graph, error) {
+	graph, ok := s[channel+"/"+arch]
+	if !ok {
+		return nil, fmt.Errorf("no graph for %s/%s: %w", channel, arch, ErrChannelNotFound)
+	}
+	return graph, nil
+}
+
+func TestDiscoverReleasesWithGraphSource(t *testing.T) {
+	source := mapGraphSource{
+		"stable-4.16/amd64": {
+			Nodes: []Node{
+				{Version: versionOrDie("4.16.1"), Payload: "p-4.16.1", Metadata: map[string]string{releaseChannelsMetadataKey: "stable-4.16"}},
+				{Version: versionOrDie("4.16.2"), Payload: "p-4.16.2", Metadata: map[string]string{releaseChannelsMetadataKey: "stable-4.16,stable-4.17,stable-4.18"}},
+			},
+			Edges: [][]int{{0, 1}},
+		},
+		"stable-4.17/amd64": {
+			Nodes: []Node{
+				{Version: versionOrDie("4.16.2"), Payload: "p-4.16.2", Metadata: map[string]string{releaseChannelsMetadataKey: "stable-4.16,stable-4.17"}},
+				{Version: versionOrDie("4.17.0"), Payload: "p-4.17.0", Metadata: map[string]string{releaseChannelsMetadataKey: "stable-4.17"}},
+			},
+			Edges: [][]int{{0, 1}},
+		},
+	}
+	hClient := &http.Client{
+		Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+			t.Fatalf("Unexpected request to %s", req.URL)
+			return nil
+		}),
+	}
+
+	releases, unfetched, err := New(hClient, WithGraphSource(source)).DiscoverReleasesWithUnfetchedChannels(nil, "stable-4.16", "x86_64", nil)
+	if err != nil {
+		t.Fatalf("Failed to discover releases: %v", err)
+	}
+	expected := ReleasesByChannel{
+		"stable-4.16": {
+			"4.16.1": {Version: "4.16.1", Payload: "p-4.16.1", Arch: "amd64", Channel: "stable-4.16", Metadata: map[string]string{releaseChannelsMetadataKey: "stable-4.16"}, AvailableUpgrades: []string{"4.16.2"}},
+			"4.16.2": {Version: "4.16.2", Payload: "p-4.16.2", Arch: "amd64", Channel: "stable-4.16", Metadata: map[string]string{releaseChannelsMetadataKey: "stable-4.16,stable-4.17,stable-4.18"}},
+		},
+		"stable-4.17": {
+			"4.16.2": {Version: "4.16.2", Payload: "p-4.16.2", Arch: "amd64", Channel: "stable-4.17", Metadata: map[string]string{releaseChannelsMetadataKey: "stable-4.16,stable-4.17"}, AvailableUpgrades: []string{"4.17.0"}},
+			"4.17.0": {Version: "4.17.0", Payload: "p-4.17.0", Arch: "amd64", Channel: "stable-4.17", Metadata: map[string]string{releaseChannelsMetadataKey: "stable-4.17"}},
+		},
+	}
+	if diff := cmp.Diff(expected, releases); diff != "" {
+		t.Errorf("Releases mismatch (-expected +got):\n%s", diff)
+	}
+	expectedUnfetched := []UnfetchedChannel{{Channel: "stable-4.18", Reason: "channel not found"}}
+	if diff := cmp.Diff(expectedUnfetched, unfetched); diff != "" {
+		t.Errorf("Unfetched channels mismatch (-expected +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,17 @@
+package cincinnaticlient
+
+// Logger receives the messages logged by the Client.
+// The keysAndValues are alternating key-value pairs describing the message,
+// so that a *slog.Logger can be used directly.
+type Logger interface {
+	Debug(msg string, keysAndValues ...any)
+	Info(msg string, keysAndValues ...any)
+	Warn(msg string, keysAndValues ...any)
+}
+
+// nopLogger is the Logger used unless one is set with WithLogger, it discards every message.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
@@ -0,0 +1,110 @@
+package cincinnaticlient
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	// releaseURLMetadataKey is the node metadata key pointing to the release notes or errata.
+	releaseURLMetadataKey = "io.openshift.upgrades.graph.release.url"
+	// previousRemoveRegexMetadataKey is the node metadata key holding a regular expression
+	// matching the previous versions whose edges to the node are removed.
+	previousRemoveRegexMetadataKey = "io.openshift.upgrades.graph.previous.remove_regex"
+)
+
+// Channels returns the channels the node belongs to, as listed in its metadata.
+func (n Node) Channels() []string {
+	return metadataChannels(n.Metadata)
+}
+
+// ReleaseURL returns the URL of the release notes or errata of the node,
+// or an empty string if its metadata doesn't have one.
+func (n Node) ReleaseURL() string {
+	return n.Metadata[releaseURLMetadataKey]
+}
+
+// PreviousRemoveRegex returns the regular expression matching the previous versions
+// whose edges to the node are removed. It returns nil if the node's metadata doesn't have one.
+func (n Node) PreviousRemoveRegex() (*regexp.Regexp, error) {
+	return metadataPreviousRemoveRegex(n.Metadata)
+}
+
+// Channels returns the channels the release belongs to, as listed in its metadata.
+func (r Release) Channels() []string {
+	return metadataChannels(r.Metadata)
+}
+
+// ReleaseURL returns the URL of the release notes or errata of the release,
+// or an empty string if its metadata doesn't have one.
+func (r Release) ReleaseURL() string {
+	return r.Metadata[releaseURLMetadataKey]
+}
+
+// PreviousRemoveRegex returns the regular expression matching the previous versions
+// whose edges to the release are removed. It returns nil if the release's metadata doesn't have one.
+func (r Release) PreviousRemoveRegex() (*regexp.Regexp, error) {
+	return metadataPreviousRemoveRegex(r.Metadata)
+}
+
+// FilterByChannelMembership returns the releases whose metadata lists the given channel,
+// e.g. to pick the stable-4.16 releases out of an aggregated result. Groups left without
+// releases are dropped. Upgrades pointing to releases that are filtered out are kept.
+func (r ReleasesByChannel) FilterByChannelMembership(channel string) ReleasesByChannel {
+	filtered := make(ReleasesByChannel)
+	for group, releases := range r {
+		for ver, release := range releases {
+			if !slices.Contains(release.Channels(), channel) {
+				continue
+			}
+			if filtered[group] == nil {
+				filtered[group] = make(VersionReleases)
+			}
+			filtered[group][ver] = release
+		}
+	}
+	return filtered
+}
+
+// ChannelsForVersion returns the channels the given version appears in: the keys of
+// the channels holding the version and the channels listed in the metadata of its releases,
+// without duplicates and in lexical order, e.g. to answer which channels promote 4.16.2.
+func (r ReleasesByChannel) ChannelsForVersion(version string) []string {
+	var channels []string
+	for channel, releases := range r {
+		release, ok := releases[version]
+		if !ok {
+			continue
+		}
+		channels = append(channels, channel)
+		channels = append(channels, release.Channels()...)
+	}
+	slices.Sort(channels)
+	return slices.Compact(channels)
+}
+
+// metadataChannels parses the comma-separated list of channels of the metadata.
+func metadataChannels(metadata map[string]string) []string {
+	var channels []string
+	for _, ch := range strings.Split(metadata[releaseChannelsMetadataKey], ",") {
+		if ch = strings.TrimSpace(ch); ch != "" {
+			channels = append(channels, ch)
+		}
+	}
+	return channels
+}
+
+// metadataPreviousRemoveRegex compiles the previous.remove_regex of the metadata, if any.
+func metadataPreviousRemoveRegex(metadata map[string]string) (*regexp.Regexp, error) {
+	expr, ok := metadata[previousRemoveRegexMetadataKey]
+	if !ok {
+		return nil, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s metadata %q: %w", previousRemoveRegexMetadataKey, expr, err)
+	}
+	return re, nil
+}
@@ -0,0 +1,175 @@
+package cincinnaticlient
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNodeMetadataAccessors(t *testing.T) {
+	var node Node
+	raw := `{
+		"version": "4.16.3",
+		"payload": "payload-4.16.3",
+		"metadata": {
+			"io.openshift.upgrades.graph.release.channels": "stable-4.16, fast-4.16,,eus-4.16",
+			"io.openshift.upgrades.graph.release.url": "https://access.redhat.com/errata/RHSA-2024:0001",
+			"io.openshift.upgrades.graph.previous.remove_regex": "4[.]15[.][0-3]"
+		}
+	}`
+	if err := json.Unmarshal([]byte(raw), &node); err != nil {
+		t.Fatalf("Failed to parse node: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"stable-4.16", "fast-4.16", "eus-4.16"}, node.Channels()); diff != "" {
+		t.Errorf("Channels mismatch (-expected +got):\n%s", diff)
+	}
+	if got, expected := node.ReleaseURL(), "https://access.redhat.com/errata/RHSA-2024:0001"; got != expected {
+		t.Errorf("Expected release URL %q, got %q", expected, got)
+	}
+	re, err := node.PreviousRemoveRegex()
+	if err != nil {
+		t.Fatalf("PreviousRemoveRegex returned an error: %v", err)
+	}
+	for ver, expected := range map[string]bool{"4.15.2": true, "4.15.5": false} {
+		if got := re.MatchString(ver); got != expected {
+			t.Errorf("Expected the remove regex to match %s: %v, got %v", ver, expected, got)
+		}
+	}
+
+	release := Release{Version: "4.16.3", Metadata: node.Metadata}
+	if diff := cmp.Diff(node.Channels(), release.Channels()); diff != "" {
+		t.Errorf("Release channels mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestMetadataAccessorsWithoutMetadata(t *testing.T) {
+	node := Node{}
+	if channels := node.Channels(); channels != nil {
+		t.Errorf("Expected no channels, got %v", channels)
+	}
+	if got := node.ReleaseURL(); got != "" {
+		t.Errorf("Expected an empty release URL, got %q", got)
+	}
+	re, err := Release{}.PreviousRemoveRegex()
+	if err != nil || re != nil {
+		t.Errorf("Expected no remove regex and no error, got %v, %v", re, err)
+	}
+}
+
+func TestPreviousRemoveRegexInvalid(t *testing.T) {
+	node := Node{Metadata: map[string]string{previousRemoveRegexMetadataKey: "4[.15"}}
+	_, err := node.PreviousRemoveRegex()
+	if err == nil {
+		t.Fatal("Expected an error, but got none")
+	}
+	if expected := "invalid io.openshift.upgrades.graph.previous.remove_regex metadata"; !strings.Contains(err.Error(), expected) {
+		t.Errorf("Expected error containing %q, got %q", expected, err.Error())
+	}
+}
+
+func TestReleaseURL(t *testing.T) {
+	node := Node{
+		Version:  versionOrDie("4.16.3"),
+		Payload:  "payload-4.16.3",
+		Metadata: map[string]string{releaseURLMetadataKey: "https://access.redhat.com/errata/RHSA-2024:0001"},
+	}
+	filter, err := New(nil).newVersionFilter(versionOrDie("4.16"), "")
+	if err != nil {
+		t.Fatalf("Failed to create the version filter: %v", err)
+	}
+	release, found := New(nil).createRelease(node, "stable-4.16", "amd64", filter)
+	if !found {
+		t.Fatal("Expected the release to be created")
+	}
+
+	if got, expected := release.ReleaseURL(), "https://access.redhat.com/errata/RHSA-2024:0001"; got != expected {
+		t.Errorf("Expected release URL %q, got %q", expected, got)
+	}
+	if got := (Release{Version: "4.16.3"}).ReleaseURL(); got != "" {
+		t.Errorf("Expected an empty release URL, got %q", got)
+	}
+}
+
+func TestFilterByChannelMembership(t *testing.T) {
+	stable := map[string]string{releaseChannelsMetadataKey: "stable-4.16,fast-4.16,candidate-4.16"}
+	fast := map[string]string{releaseChannelsMetadataKey: "fast-4.16, candidate-4.16"}
+	releases := ReleasesByChannel{
+		"stable": {
+			"4.16.1": {Version: "4.16.1", Metadata: stable},
+			"4.16.2": {Version: "4.16.2", Metadata: stable},
+		},
+		"fast": {
+			"4.16.1": {Version: "4.16.1", Metadata: stable},
+			"4.16.3": {Version: "4.16.3", Metadata: fast},
+		},
+		"candidate": {
+			"4.16.4": {Version: "4.16.4"},
+		},
+	}
+
+	tests := []struct {
+		channel  string
+		expected ReleasesByChannel
+	}{
+		{
+			channel: "stable-4.16",
+			expected: ReleasesByChannel{
+				"stable": {
+					"4.16.1": {Version: "4.16.1", Metadata: stable},
+					"4.16.2": {Version: "4.16.2", Metadata: stable},
+				},
+				"fast": {
+					"4.16.1": {Version: "4.16.1", Metadata: stable},
+				},
+			},
+		},
+		{
+			channel: "candidate-4.16",
+			expected: ReleasesByChannel{
+				"stable": {
+					"4.16.1": {Version: "4.16.1", Metadata: stable},
+					"4.16.2": {Version: "4.16.2", Metadata: stable},
+				},
+				"fast": {
+					"4.16.1": {Version: "4.16.1", Metadata: stable},
+					"4.16.3": {Version: "4.16.3", Metadata: fast},
+				},
+			},
+		},
+		{
+			channel:  "eus-4.16",
+			expected: ReleasesByChannel{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.channel, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, releases.FilterByChannelMembership(tc.channel)); diff != "" {
+				t.Errorf("Filtered releases mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestChannelsForVersion(t *testing.T) {
+	releases := ReleasesByChannel{
+		"stable-4.16": {
+			"4.16.2": Release{Version: "4.16.2", Metadata: map[string]string{releaseChannelsMetadataKey: "fast-4.16,stable-4.16"}},
+		},
+		"stable-4.17": {
+			"4.16.2": Release{Version: "4.16.2", Metadata: map[string]string{releaseChannelsMetadataKey: "eus-4.16,stable-4.17"}},
+			"4.17.0": Release{Version: "4.17.0", Metadata: map[string]string{releaseChannelsMetadataKey: "candidate-4.17,stable-4.17"}},
+		},
+	}
+
+	expected := []string{"eus-4.16", "fast-4.16", "stable-4.16", "stable-4.17"}
+	if diff := cmp.Diff(expected, releases.ChannelsForVersion("4.16.2")); diff != "" {
+		t.Errorf("Channels mismatch (-expected +got):\n%s", diff)
+	}
+	if got := releases.ChannelsForVersion("4.16.3"); len(got) != 0 {
+		t.Errorf("Expected no channels for an unknown version, got %v", got)
+	}
+}
@@ -0,0 +1,12 @@
+package cincinnaticlient
+
+import "time"
+
+// Observer is notified about the graph requests made by the Client,
+// e.g. to export them as metrics.
+type Observer interface {
+	// ObserveFetch is called once for every graph request sent to the server with the
+	// status code of the response, or 0 if no response was received, and the time it took.
+	// Graphs served from the memo or a fresh cache entry are not observed.
+	ObserveFetch(channel, arch string, status int, d time.Duration)
+}
@@ -0,0 +1,393 @@
+package cincinnaticlient
+
+import (
+	"crypto/tls"
+	"maps"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"golang.org/x/time/rate"
+)
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithGraphURL sets the graph URL used by DiscoverReleases when it is called with a nil URL.
+// The URL is validated by DiscoverReleases.
+func WithGraphURL(u *url.URL) Option {
+	return func(c *Client) {
+		c.graphURL = u
+	}
+}
+
+// WithRiskEvaluator makes the client consult the given evaluator when deciding
+// whether conditional edges can be applied, instead of the allowedConditionalEdgeRisks
+// passed to DiscoverReleases.
+func WithRiskEvaluator(evaluator RiskEvaluator) Option {
+	return func(c *Client) {
+		c.riskEvaluator = evaluator
+	}
+}
+
+// WithVersionConstraint restricts discovered releases to the versions satisfying
+// the given constraint, e.g. ">=4.16.0 <4.18.0", using the Masterminds/semver syntax.
+// Upgrade edges pointing to versions outside of the constraint are dropped.
+// An invalid constraint is reported by DiscoverReleases.
+func WithVersionConstraint(constraint string) Option {
+	return func(c *Client) {
+		c.versionConstraint = constraint
+	}
+}
+
+// WithMaxVersion sets an upper bound on discovered releases and channels, e.g. "4.17".
+// The ceiling is compared only up to the number of segments it has,
+// so "4.17" keeps every 4.17.z release while channels above 4.17 are never fetched.
+// An invalid version is reported by DiscoverReleases.
+func WithMaxVersion(maxVersion string) Option {
+	return func(c *Client) {
+		c.maxVersion = maxVersion
+	}
+}
+
+// WithMinVersion overrides the minVersion implied by the start channel, e.g. "4.16.5" to seed
+// the discovery from stable-4.16 but only keep the releases from 4.16.5 on.
+// The channels are still compared with the start channel version.
+// A version that is invalid or lower than the start channel version is reported by DiscoverReleases.
+func WithMinVersion(minVersion string) Option {
+	return func(c *Client) {
+		c.minVersion = minVersion
+	}
+}
+
+// WithExclusiveMinVersion makes discovery keep only releases and channels
+// strictly newer than the minimum version derived from the start channel.
+// By default the minimum version is inclusive.
+func WithExclusiveMinVersion() Option {
+	return func(c *Client) {
+		c.exclusiveMinVersion = true
+	}
+}
+
+// WithOnlyNewerChannels makes discovery follow only the channels strictly newer than the start channel,
+// e.g. stable-4.17 but not fast-4.16 when starting from stable-4.16.
+// Unlike WithExclusiveMinVersion, the releases of the fetched channels are not affected.
+func WithOnlyNewerChannels() Option {
+	return func(c *Client) {
+		c.onlyNewerChannels = true
+	}
+}
+
+// WithIncludePreReleases controls whether releases with a pre-release component,
+// e.g. 4.16.1-rc.1, are discovered. Pre-releases are included by default.
+//
+// Note that per semver a pre-release sorts below its GA version, so 4.16.0-rc.3 is
+// below the 4.16 minimum derived from stable-4.16 and is never included,
+// while 4.16.1-rc.1 is above it and is included unless this option is set to false.
+func WithIncludePreReleases(include bool) Option {
+	return func(c *Client) {
+		c.excludePreReleases = !include
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every graph request.
+// By default DefaultUserAgent is used.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithBearerToken attaches an "Authorization: Bearer <token>" header to every graph request.
+// The token is only ever placed in the request header, it isn't included in URLs or errors.
+func WithBearerToken(token string) Option {
+	return WithRequestModifier(func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	})
+}
+
+// WithRequestModifier registers a function called on every graph request right before it is sent.
+// It can be used to add arbitrary headers or otherwise customize the request.
+// The modifiers are called in the order they were registered.
+func WithRequestModifier(modify func(*http.Request)) Option {
+	return func(c *Client) {
+		c.requestModifiers = append(c.requestModifiers, modify)
+	}
+}
+
+// WithCache stores fetched graphs under dir and serves them without contacting
+// the server until they are older than ttl. Entries are keyed by the full request URL.
+// A max-age Cache-Control directive of the response takes precedence over the ttl,
+// no-store disables caching of the response and expired entries are revalidated
+// using their ETag, when the server provided one.
+func WithCache(dir string, ttl time.Duration) Option {
+	return func(c *Client) {
+		c.cache = newDiskCache(dir, ttl)
+	}
+}
+
+// WithVisitHook registers a function called once for every channel visited during discovery,
+// in the order the channels are visited, with the number of nodes in the channel's graph.
+// It helps to understand why certain channels were or weren't scanned.
+func WithVisitHook(hook func(channel string, nodeCount int)) Option {
+	return func(c *Client) {
+		c.visitHook = hook
+	}
+}
+
+// WithLogger makes the client log the graphs it fetches, the channels it discovers
+// and the nodes it skips to the given logger. By default nothing is logged.
+func WithLogger(logger Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithObserver makes the client report every graph request it sends to the given observer.
+func WithObserver(observer Observer) Option {
+	return func(c *Client) {
+		c.observer = observer
+	}
+}
+
+// WithExtraQueryParams adds the given query parameters to every graph request,
+// e.g. the id or version expected by some deployments of the update service.
+// The channel and arch parameters set by the client take precedence over them.
+func WithExtraQueryParams(params map[string]string) Option {
+	return func(c *Client) {
+		c.extraQueryParams = maps.Clone(params)
+	}
+}
+
+// WithArchParamName sets the name of the query parameter carrying the architecture
+// in the graph requests, e.g. "architecture" for deployments of the update service
+// that don't understand the default "arch". An invalid name is reported by DiscoverReleases.
+func WithArchParamName(name string) Option {
+	return func(c *Client) {
+		c.archParamName = name
+	}
+}
+
+// WithChannelAllowList restricts the channels discovered from the release metadata
+// to the ones matching any of the given glob patterns, e.g. "stable-*".
+// The start channel is always fetched. Invalid patterns are reported by DiscoverReleases.
+func WithChannelAllowList(patterns []string) Option {
+	return func(c *Client) {
+		c.channelAllowList = slices.Clone(patterns)
+	}
+}
+
+// WithChannelDenyList prevents the channels matching any of the given glob patterns,
+// e.g. "candidate-*", from being discovered. It takes precedence over WithChannelAllowList.
+// Invalid patterns are reported by DiscoverReleases.
+func WithChannelDenyList(patterns []string) Option {
+	return func(c *Client) {
+		c.channelDenyList = slices.Clone(patterns)
+	}
+}
+
+// WithAdditionalChannelPrefixes makes discovery follow the channels with the given prefixes,
+// e.g. "eus", in addition to the ones sharing the prefix of the start channel.
+// The same version bounds apply, so only channels at or above the minVersion are followed.
+func WithAdditionalChannelPrefixes(prefixes []string) Option {
+	return func(c *Client) {
+		for _, prefix := range prefixes {
+			c.additionalChannelPrefixes = append(c.additionalChannelPrefixes, strings.TrimSuffix(prefix, "-")+"-")
+		}
+	}
+}
+
+// WithSortUpgrades makes discovery sort the AvailableUpgrades of every release
+// in ascending semantic-version order once all channels were processed.
+// The upgrades are already sorted while the edges are processed, so the option
+// only adds the validation of their versions: an upgrade with an invalid version is reported by DiscoverReleases.
+func WithSortUpgrades() Option {
+	return func(c *Client) {
+		c.sortUpgrades = true
+	}
+}
+
+// WithRateLimit limits the rate of the graph requests sent to the server
+// to r requests per second with bursts of up to burst requests.
+// Graphs served from the memo or a fresh cache entry don't count against the limit.
+func WithRateLimit(r rate.Limit, burst int) Option {
+	return func(c *Client) {
+		c.limiter = rate.NewLimiter(r, burst)
+	}
+}
+
+// WithVersionParser replaces version.NewVersion as the parser of the versions of the graph nodes,
+// of the channels, of the conditional edges and of the ceilings set with WithMaxVersion or
+// DiscoverToTarget, e.g. to normalize vendor-specific versions that deviate from strict semver.
+// The versions of the discovered releases are the String form of the parsed versions.
+func WithVersionParser(parse func(string) (*version.Version, error)) Option {
+	return func(c *Client) {
+		c.versionParser = parse
+	}
+}
+
+// WithKeepEdgeTargetsBelowMin makes discovery keep the releases below the minVersion
+// that discovered releases can upgrade to, flagged with BelowMin, along with the edges pointing to them.
+// By default such edges are dropped.
+func WithKeepEdgeTargetsBelowMin() Option {
+	return func(c *Client) {
+		c.keepEdgeTargetsBelowMin = true
+	}
+}
+
+// WithIncludeBelowMinInStart makes discovery keep all the releases of the start channel,
+// including the ones below the minVersion, flagged with BelowMin along with the edges between them,
+// e.g. to compare the start channel before and after the minVersion filter.
+// The releases of the other channels are still filtered by the minVersion.
+func WithIncludeBelowMinInStart() Option {
+	return func(c *Client) {
+		c.includeBelowMinInStart = true
+	}
+}
+
+// WithHeader adds a header to every graph request, e.g. an X-Request-ID required by a proxy.
+// It can be used multiple times, values of the same key are all sent.
+// The Accept, Accept-Encoding and User-Agent headers are set by the client and can't be replaced,
+// use WithUserAgent or WithRequestModifier instead.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		if c.headers == nil {
+			c.headers = make(http.Header)
+		}
+		c.headers.Add(key, value)
+	}
+}
+
+// WithClientTrace attaches the httptrace.ClientTrace returned by trace to the context of every graph request,
+// e.g. to report the DNS, connect and TLS timings when debugging a mirror.
+// trace is called with the request once the request modifiers have been applied, a nil trace is ignored.
+func WithClientTrace(trace func(*http.Request) *httptrace.ClientTrace) Option {
+	return func(c *Client) {
+		c.clientTrace = trace
+	}
+}
+
+// WithGraphSource makes discovery get the graphs from the source instead of fetching them from the graph URL.
+// The graph URL, the request and the cache options only apply to PlanFetches and Ping then.
+func WithGraphSource(source GraphSource) Option {
+	return func(c *Client) {
+		c.graphSource = source
+	}
+}
+
+// WithExpandMultiArch makes a discovery for the multi arch walk the channels once for every concrete arch,
+// e.g. amd64 and arm64, instead of fetching the combined multi-arch graphs.
+// The releases are tagged with their arch and keyed by ArchChannel, e.g. stable-4.16/arm64,
+// since the same version has a different payload for every arch. Other archs are not affected.
+func WithExpandMultiArch() Option {
+	return func(c *Client) {
+		c.expandMultiArch = true
+	}
+}
+
+// WithExcludeVersions makes discovery skip the releases of the given versions, e.g. known-bad ones,
+// along with all the edges and conditional edges pointing to or leaving them.
+// An invalid version is reported by DiscoverReleases.
+func WithExcludeVersions(versions []string) Option {
+	return func(c *Client) {
+		c.excludeVersions = slices.Clone(versions)
+	}
+}
+
+// WithRequireNonEmptyStart makes DiscoverReleases fail when the graph of the start channel
+// has no releases at or above the minVersion, e.g. because of a typo in the channel name.
+// By default such a discovery returns no releases for the start channel and no error.
+func WithRequireNonEmptyStart() Option {
+	return func(c *Client) {
+		c.requireNonEmptyStart = true
+	}
+}
+
+// WithLenientDownstream makes DiscoverReleases skip the channels other than the start channel
+// whose graph can't be fetched for any reason, e.g. a 500 Internal Server Error, instead of failing.
+// The skipped channels are logged as warnings and reported by DiscoverReleasesWithUnfetchedChannels.
+// A failure to fetch the start channel still aborts the discovery.
+func WithLenientDownstream() Option {
+	return func(c *Client) {
+		c.lenientDownstream = true
+	}
+}
+
+// WithMaxNodesPerChannel makes DiscoverReleases fail when the graph of a channel has more than n nodes,
+// to guard against a broken or malicious server returning an enormous graph.
+// A limit of zero or less, the default, disables the check.
+func WithMaxNodesPerChannel(n int) Option {
+	return func(c *Client) {
+		c.maxNodesPerChannel = n
+	}
+}
+
+// WithMaxResponseBytes limits the size of a graph response, after decompression, to n bytes,
+// so that a huge response fails instead of exhausting the memory.
+// It defaults to DefaultMaxResponseBytes, a limit of zero or less disables the check.
+func WithMaxResponseBytes(n int64) Option {
+	return func(c *Client) {
+		c.maxResponseBytes = n
+	}
+}
+
+// WithRequestBuilder replaces the GET request carrying the channel and arch in the query
+// with the request returned by build, e.g. a POST request with a JSON body describing the cluster
+// for proxies of the update service that require one. build is called with the graph URL of the discovery
+// and the normalized arch. The headers, request modifiers and client trace are still applied to the request,
+// and the graphs are still memoized and cached by the URL of the default GET request.
+func WithRequestBuilder(build func(base *url.URL, channel, arch string) (*http.Request, error)) Option {
+	return func(c *Client) {
+		c.requestBuilder = build
+	}
+}
+
+// WithSkipInvalidEdges makes discovery drop the malformed edges and the edges with out-of-range node indices
+// of a graph, logging a warning for each of them, instead of failing. By default such an edge aborts the discovery.
+func WithSkipInvalidEdges() Option {
+	return func(c *Client) {
+		c.skipInvalidEdges = true
+	}
+}
+
+// WithProxyURL makes the client send the graph requests through the proxy at the given URL,
+// instead of the one set in the environment, e.g. with HTTPS_PROXY.
+// It only applies when the http.Client passed to New has no Transport,
+// a Transport set by the caller wins and has to be configured by the caller.
+func WithProxyURL(u *url.URL) Option {
+	return func(c *Client) {
+		c.proxyURL = u
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the connections to the graph API,
+// e.g. to trust the private CA of an air-gapped update service.
+// Like WithProxyURL, it only applies when the http.Client passed to New has no Transport.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(c *Client) {
+		c.tlsConfig = config
+	}
+}
+
+// WithRootCAFile makes the client trust only the CA certificates of the given PEM file
+// when connecting to the graph API. It takes precedence over the RootCAs of WithTLSConfig.
+// Like WithProxyURL, it only applies when the http.Client passed to New has no Transport.
+// A file that can't be read or holds no certificate is reported by DiscoverReleases.
+func WithRootCAFile(path string) Option {
+	return func(c *Client) {
+		c.rootCAFile = path
+	}
+}
+
+// WithRawCapture calls capture with the URL and the decompressed body of every graph response
+// read from the server, before it is parsed, so that invalid responses are captured too,
+// e.g. to save them as test data. Graphs served from the memo or the cache are not captured.
+func WithRawCapture(capture func(url string, body []byte)) Option {
+	return func(c *Client) {
+		c.rawCapture = capture
+	}
+}
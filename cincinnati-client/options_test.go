@@ -0,0 +1,1606 @@
+package cincinnaticlient
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/go-version"
+	"golang.org/x/time/rate"
+)
+
+const testGraphURL = DefaultGraphURL
+
+func TestDiscoverReleasesWithVersionConstraint(t *testing.T) {
+	tests := []struct {
+		name          string
+		constraint    string
+		expected      ReleasesByChannel
+		expectedError string
+	}{
+		{
+			name:       "constraint excludes the top of the range and its edges",
+			constraint: ">=4.16.0 <4.17.0",
+			expected: ReleasesByChannel{
+				"stable-4.16": VersionReleases{
+					"4.16.1": Release{
+						Version:           "4.16.1",
+						Channel:           "stable-4.16",
+						Arch:              "amd64",
+						Payload:           "payload-4.16.1",
+						AvailableUpgrades: []string{"4.16.2"},
+					},
+					"4.16.2": Release{
+						Version: "4.16.2",
+						Channel: "stable-4.16",
+						Arch:    "amd64",
+						Payload: "payload-4.16.2",
+					},
+				},
+			},
+		},
+		{
+			name:          "invalid constraint",
+			constraint:    ">=4.16.0 <",
+			expectedError: `invalid version constraint ">=4.16.0 <"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hClient := fakeHTTPClientForFiles(t, map[string]string{
+				testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-version-constraint.json",
+			})
+			target := New(hClient, WithVersionConstraint(tc.constraint))
+
+			releases, err := target.DiscoverReleases(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64", []string{"RiskA"})
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to discover releases: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, releases); diff != "" {
+				t.Errorf("Releases mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiscoverReleasesWithMaxVersion(t *testing.T) {
+	hClient := fakeHTTPClientForFiles(t, map[string]string{
+		testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-max-version.json",
+		testGraphURL + "?arch=amd64&channel=stable-4.17": "testdata/discover-releases-stable-4.17.json",
+		// stable-4.18 is deliberately not mapped, fetching it fails the test
+	})
+	target := New(hClient, WithMaxVersion("4.17"))
+
+	releases, err := target.DiscoverReleases(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64", nil)
+	if err != nil {
+		t.Fatalf("Failed to discover releases: %v", err)
+	}
+
+	expected := ReleasesByChannel{
+		"stable-4.16": VersionReleases{
+			"4.16.2": Release{
+				Version:  "4.16.2",
+				Channel:  "stable-4.16",
+				Arch:     "amd64",
+				Payload:  "payload-4.16.2",
+				Metadata: map[string]string{"io.openshift.upgrades.graph.release.channels": "stable-4.16,stable-4.17,stable-4.18"},
+			},
+		},
+		"stable-4.17": VersionReleases{
+			"4.17.5": Release{
+				Version: "4.17.5",
+				Channel: "stable-4.17",
+				Arch:    "amd64",
+				Payload: "payload-4.17",
+			},
+		},
+	}
+	if diff := cmp.Diff(expected, releases); diff != "" {
+		t.Errorf("Releases mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestVersionFilterBelowCeiling(t *testing.T) {
+	tests := []struct {
+		maxVersion string
+		version    string
+		expected   bool
+	}{
+		{maxVersion: "4.17", version: "4.17.5", expected: true},
+		{maxVersion: "4.17", version: "4.17", expected: true},
+		{maxVersion: "4.17", version: "4.18.0", expected: false},
+		{maxVersion: "4.17.3", version: "4.17.3", expected: true},
+		{maxVersion: "4.17.3", version: "4.17.5", expected: false},
+		{maxVersion: "4", version: "4.99.1", expected: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.maxVersion+" vs "+tc.version, func(t *testing.T) {
+			target := New(nil, WithMaxVersion(tc.maxVersion))
+			filter, err := target.newVersionFilter(versionOrDie("4.0"), target.maxVersion)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := filter.belowCeiling(versionOrDie(tc.version)); got != tc.expected {
+				t.Errorf("Expected belowCeiling to return %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestDiscoverReleasesWithExclusiveMinVersion(t *testing.T) {
+	tests := []struct {
+		name             string
+		opts             []Option
+		expectedVersions []string
+		expectedChannels []string
+	}{
+		{
+			name:             "inclusive by default",
+			expectedVersions: []string{"4.16.0", "4.16.1"},
+			expectedChannels: []string{"stable-4.16", "stable-4.17"},
+		},
+		{
+			name:             "exclusive",
+			opts:             []Option{WithExclusiveMinVersion()},
+			expectedVersions: []string{"4.16.1"},
+			expectedChannels: []string{"stable-4.17"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hClient := fakeHTTPClientForFiles(t, map[string]string{
+				testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-boundary.json",
+			})
+			target := New(hClient, tc.opts...)
+
+			releases, err := target.DiscoverReleases(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64", nil)
+			if err != nil {
+				t.Fatalf("Failed to discover releases: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedVersions, sortedVersions(releases["stable-4.16"])); diff != "" {
+				t.Errorf("Versions mismatch (-expected +got):\n%s", diff)
+			}
+
+			filter, err := target.newVersionFilter(versionOrDie("4.16"), target.maxVersion)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			node := Node{Metadata: map[string]string{"io.openshift.upgrades.graph.release.channels": "stable-4.16,stable-4.17"}}
+			if diff := cmp.Diff(tc.expectedChannels, target.discoverNewChannels(node, "stable-", filter)); diff != "" {
+				t.Errorf("Channels mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiscoverReleasesWithOnlyNewerChannels(t *testing.T) {
+	tests := []struct {
+		name             string
+		opts             []Option
+		expectedChannels []string
+	}{
+		{
+			name:             "same minor followed by default",
+			expectedChannels: []string{"stable-4.16", "stable-4.17"},
+		},
+		{
+			name:             "only newer channels",
+			opts:             []Option{WithOnlyNewerChannels()},
+			expectedChannels: []string{"stable-4.17"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			target := New(&http.Client{}, tc.opts...)
+			filter, err := target.newVersionFilter(versionOrDie("4.16"), target.maxVersion)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			node := Node{Metadata: map[string]string{"io.openshift.upgrades.graph.release.channels": "stable-4.16,stable-4.17"}}
+			if diff := cmp.Diff(tc.expectedChannels, target.discoverNewChannels(node, "stable-", filter)); diff != "" {
+				t.Errorf("Channels mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiscoverReleasesWithIncludePreReleases(t *testing.T) {
+	tests := []struct {
+		name             string
+		opts             []Option
+		expectedVersions []string
+	}{
+		{
+			name:             "pre-releases above the minimum are included by default",
+			expectedVersions: []string{"4.16.0", "4.16.1-rc.1", "4.16.1"},
+		},
+		{
+			name:             "pre-releases included explicitly",
+			opts:             []Option{WithIncludePreReleases(true)},
+			expectedVersions: []string{"4.16.0", "4.16.1-rc.1", "4.16.1"},
+		},
+		{
+			name:             "pre-releases excluded",
+			opts:             []Option{WithIncludePreReleases(false)},
+			expectedVersions: []string{"4.16.0", "4.16.1"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hClient := fakeHTTPClientForFiles(t, map[string]string{
+				testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-pre-releases.json",
+			})
+
+			releases, err := New(hClient, tc.opts...).DiscoverReleases(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64", nil)
+			if err != nil {
+				t.Fatalf("Failed to discover releases: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedVersions, sortedVersions(releases["stable-4.16"])); diff != "" {
+				t.Errorf("Versions mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiscoverReleasesGraphURL(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          []Option
+		graphURL      *url.URL
+		responses     map[string]string
+		expectedError string
+	}{
+		{
+			name: "default graph URL is applied",
+			responses: map[string]string{
+				DefaultGraphURL + "?arch=amd64&channel=stable-4.18": "testdata/discover-releases-stable-4.18.json",
+			},
+		},
+		{
+			name: "configured graph URL is applied",
+			opts: []Option{WithGraphURL(rawURLtoURLOrDie("https://mirror.example.com/graph"))},
+			responses: map[string]string{
+				"https://mirror.example.com/graph?arch=amd64&channel=stable-4.18": "testdata/discover-releases-stable-4.18.json",
+			},
+		},
+		{
+			name:     "explicit graph URL wins over the configured one",
+			opts:     []Option{WithGraphURL(rawURLtoURLOrDie("https://mirror.example.com/graph"))},
+			graphURL: rawURLtoURLOrDie("https://other.example.com/graph?id=1"),
+			responses: map[string]string{
+				"https://other.example.com/graph?arch=amd64&channel=stable-4.18&id=1": "testdata/discover-releases-stable-4.18.json",
+			},
+		},
+		{
+			name:          "unsupported scheme is rejected",
+			graphURL:      rawURLtoURLOrDie("ftp://mirror.example.com/graph"),
+			expectedError: `invalid cincinnati graph URL "ftp://mirror.example.com/graph": scheme must be http or https`,
+		},
+		{
+			name:          "colliding channel query is rejected",
+			opts:          []Option{WithGraphURL(rawURLtoURLOrDie("https://mirror.example.com/graph?channel=fast-4.16"))},
+			expectedError: `the channel query parameter is set by the client`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			target := New(fakeHTTPClientForFiles(t, tc.responses), tc.opts...)
+
+			releases, err := target.DiscoverReleases(tc.graphURL, "stable-4.18", "amd64", nil)
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to discover releases: %v", err)
+			}
+			if _, ok := releases["stable-4.18"]["4.18.1"]; !ok {
+				t.Errorf("Expected release 4.18.1 to be discovered, got %v", releases)
+			}
+		})
+	}
+}
+
+// fakeHTTPClientRecordingRequests returns an http.Client that records the
+// requests it receives and serves the given testdata file with a 200 status code.
+func fakeHTTPClientRecordingRequests(t *testing.T, filename string, requests *[]*http.Request) *http.Client {
+	return &http.Client{
+		Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+			*requests = append(*requests, req)
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatalf("Failed to read file %s: %v", filename, err)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(data)),
+				Header:     make(http.Header),
+			}
+		}),
+	}
+}
+
+func TestFetchGraphUserAgent(t *testing.T) {
+	tests := []struct {
+		name              string
+		opts              []Option
+		expectedUserAgent string
+	}{
+		{
+			name:              "default user agent",
+			expectedUserAgent: "cincinnati-installation-versions/" + Version,
+		},
+		{
+			name:              "custom user agent",
+			opts:              []Option{WithUserAgent("my-tool/1.2.3")},
+			expectedUserAgent: "my-tool/1.2.3",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests []*http.Request
+			hClient := fakeHTTPClientRecordingRequests(t, "testdata/fetch-graph-valid-response.json", &requests)
+
+			if _, err := New(hClient, tc.opts...).fetchGraph(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64"); err != nil {
+				t.Fatalf("fetchGraph returned an error: %v", err)
+			}
+			if len(requests) != 1 {
+				t.Fatalf("Expected exactly one request, got %d", len(requests))
+			}
+			if got := requests[0].Header.Get("User-Agent"); got != tc.expectedUserAgent {
+				t.Errorf("Expected User-Agent %q, got %q", tc.expectedUserAgent, got)
+			}
+		})
+	}
+}
+
+func TestFetchGraphWithBearerToken(t *testing.T) {
+	var requests []*http.Request
+	hClient := fakeHTTPClientRecordingRequests(t, "testdata/fetch-graph-valid-response.json", &requests)
+
+	if _, err := New(hClient, WithBearerToken("secret-token")).fetchGraph(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64"); err != nil {
+		t.Fatalf("fetchGraph returned an error: %v", err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("Expected exactly one request, got %d", len(requests))
+	}
+	if got := requests[0].Header.Get("Authorization"); got != "Bearer secret-token" {
+		t.Errorf("Expected Authorization header %q, got %q", "Bearer secret-token", got)
+	}
+}
+
+func TestFetchGraphWithRequestModifier(t *testing.T) {
+	var requests []*http.Request
+	hClient := fakeHTTPClientRecordingRequests(t, "testdata/fetch-graph-valid-response.json", &requests)
+	target := New(hClient,
+		WithRequestModifier(func(req *http.Request) { req.Header.Set("X-Org-ID", "org-1") }),
+		WithRequestModifier(func(req *http.Request) { req.Header.Set("X-Request-ID", "req-1") }),
+	)
+
+	if _, err := target.fetchGraph(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64"); err != nil {
+		t.Fatalf("fetchGraph returned an error: %v", err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("Expected exactly one request, got %d", len(requests))
+	}
+	for header, expected := range map[string]string{"X-Org-ID": "org-1", "X-Request-ID": "req-1", "Accept": "application/json"} {
+		if got := requests[0].Header.Get(header); got != expected {
+			t.Errorf("Expected %s header %q, got %q", header, expected, got)
+		}
+	}
+}
+
+func TestDiscoverReleasesWithVisitHook(t *testing.T) {
+	type visit struct {
+		Channel   string
+		NodeCount int
+	}
+	hClient := fakeHTTPClientForFiles(t, map[string]string{
+		testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-overlapping-channels.json",
+		testGraphURL + "?arch=amd64&channel=stable-4.17": "testdata/discover-releases-stable-4.17-overlapping-channels.json",
+		testGraphURL + "?arch=amd64&channel=stable-4.18": "testdata/discover-releases-stable-4.18.json",
+	})
+	var visits []visit
+	target := New(hClient, WithVisitHook(func(channel string, nodeCount int) {
+		visits = append(visits, visit{Channel: channel, NodeCount: nodeCount})
+	}))
+
+	if _, err := target.DiscoverReleases(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64", nil); err != nil {
+		t.Fatalf("Failed to discover releases: %v", err)
+	}
+	expected := []visit{
+		{Channel: "stable-4.16", NodeCount: 2},
+		{Channel: "stable-4.17", NodeCount: 1},
+		{Channel: "stable-4.18", NodeCount: 1},
+	}
+	if diff := cmp.Diff(expected, visits); diff != "" {
+		t.Errorf("Visits mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+type logLine struct {
+	Level         string
+	Msg           string
+	KeysAndValues []any
+}
+
+type capturingLogger struct {
+	lines []logLine
+}
+
+func (l *capturingLogger) Debug(msg string, keysAndValues ...any) {
+	l.lines = append(l.lines, logLine{Level: "debug", Msg: msg, KeysAndValues: keysAndValues})
+}
+
+func (l *capturingLogger) Info(msg string, keysAndValues ...any) {
+	l.lines = append(l.lines, logLine{Level: "info", Msg: msg, KeysAndValues: keysAndValues})
+}
+
+func (l *capturingLogger) Warn(msg string, keysAndValues ...any) {
+	l.lines = append(l.lines, logLine{Level: "warn", Msg: msg, KeysAndValues: keysAndValues})
+}
+
+func TestDiscoverReleasesWithLogger(t *testing.T) {
+	hClient := fakeHTTPClientForFiles(t, map[string]string{
+		testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-logging.json",
+		testGraphURL + "?arch=amd64&channel=stable-4.17": "testdata/discover-releases-stable-4.17.json",
+	})
+	logger := &capturingLogger{}
+
+	if _, err := New(hClient, WithLogger(logger)).DiscoverReleases(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64", nil); err != nil {
+		t.Fatalf("Failed to discover releases: %v", err)
+	}
+	expected := []logLine{
+		{Level: "debug", Msg: "fetching graph", KeysAndValues: []any{"channel", "stable-4.16", "arch", "amd64", "url", testGraphURL + "?arch=amd64&channel=stable-4.16"}},
+		{Level: "debug", Msg: "skipping node", KeysAndValues: []any{"channel", "stable-4.16", "version", "4.15.9", "reason", "below minimum version"}},
+		{Level: "info", Msg: "discovered channel", KeysAndValues: []any{"channel", "stable-4.17", "from", "stable-4.16"}},
+		{Level: "debug", Msg: "fetching graph", KeysAndValues: []any{"channel", "stable-4.17", "arch", "amd64", "url", testGraphURL + "?arch=amd64&channel=stable-4.17"}},
+	}
+	if diff := cmp.Diff(expected, logger.lines); diff != "" {
+		t.Errorf("Log lines mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+type observation struct {
+	Channel string
+	Arch    string
+	Status  int
+}
+
+type fakeObserver struct {
+	observations []observation
+}
+
+func (o *fakeObserver) ObserveFetch(channel, arch string, status int, d time.Duration) {
+	o.observations = append(o.observations, observation{Channel: channel, Arch: arch, Status: status})
+}
+
+func TestDiscoverReleasesWithObserver(t *testing.T) {
+	hClient := fakeHTTPClientForFiles(t, map[string]string{
+		testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-with-4.17-4.18.json",
+		testGraphURL + "?arch=amd64&channel=stable-4.17": "testdata/discover-releases-stable-4.17.json",
+		testGraphURL + "?arch=amd64&channel=stable-4.18": "testdata/discover-releases-stable-4.18.json",
+	})
+	observer := &fakeObserver{}
+
+	if _, err := New(hClient, WithObserver(observer)).DiscoverReleases(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64", nil); err != nil {
+		t.Fatalf("Failed to discover releases: %v", err)
+	}
+	expected := []observation{
+		{Channel: "stable-4.16", Arch: "amd64", Status: http.StatusOK},
+		{Channel: "stable-4.17", Arch: "amd64", Status: http.StatusOK},
+		{Channel: "stable-4.18", Arch: "amd64", Status: http.StatusOK},
+	}
+	if diff := cmp.Diff(expected, observer.observations); diff != "" {
+		t.Errorf("Observations mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestFetchGraphObservesFailedRequests(t *testing.T) {
+	hClient := &http.Client{
+		Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+			return &http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Body:       io.NopCloser(strings.NewReader("")),
+			}
+		}),
+	}
+	observer := &fakeObserver{}
+
+	if _, err := New(hClient, WithObserver(observer)).fetchGraph(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "x86_64"); err == nil {
+		t.Fatal("Expected an error, but got none")
+	}
+	expected := []observation{{Channel: "stable-4.16", Arch: "amd64", Status: http.StatusServiceUnavailable}}
+	if diff := cmp.Diff(expected, observer.observations); diff != "" {
+		t.Errorf("Observations mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestFetchGraphWithExtraQueryParams(t *testing.T) {
+	var requests []*http.Request
+	hClient := fakeHTTPClientRecordingRequests(t, "testdata/fetch-graph-valid-response.json", &requests)
+	target := New(hClient, WithExtraQueryParams(map[string]string{
+		"id":      "cluster-id",
+		"version": "4.16.1",
+		"channel": "ignored",
+	}))
+
+	if _, err := target.fetchGraph(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64"); err != nil {
+		t.Fatalf("fetchGraph returned an error: %v", err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("Expected exactly one request, got %d", len(requests))
+	}
+	expected := testGraphURL + "?arch=amd64&channel=stable-4.16&id=cluster-id&version=4.16.1"
+	if got := requests[0].URL.String(); got != expected {
+		t.Errorf("Expected request URL %q, got %q", expected, got)
+	}
+}
+
+func TestDiscoverReleasesWithChannelAllowAndDenyLists(t *testing.T) {
+	tests := []struct {
+		name             string
+		opts             []Option
+		expectedChannels []string
+		expectedError    string
+	}{
+		{
+			name:             "no lists follow every channel",
+			expectedChannels: []string{"stable-4.16", "stable-4.17", "stable-4.18"},
+		},
+		{
+			name:             "allow list restricts the discovered channels",
+			opts:             []Option{WithChannelAllowList([]string{"stable-4.17"})},
+			expectedChannels: []string{"stable-4.16", "stable-4.17"},
+		},
+		{
+			name:             "deny list takes precedence over the allow list",
+			opts:             []Option{WithChannelAllowList([]string{"stable-*"}), WithChannelDenyList([]string{"stable-4.18"})},
+			expectedChannels: []string{"stable-4.16", "stable-4.17"},
+		},
+		{
+			name:          "invalid pattern",
+			opts:          []Option{WithChannelDenyList([]string{"stable-[4"})},
+			expectedError: `invalid channel pattern "stable-[4"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hClient := fakeHTTPClientForFiles(t, map[string]string{
+				testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-channel-tiers.json",
+				testGraphURL + "?arch=amd64&channel=stable-4.17": "testdata/discover-releases-stable-4.17.json",
+				testGraphURL + "?arch=amd64&channel=stable-4.18": "testdata/discover-releases-stable-4.18.json",
+			})
+			var channels []string
+			opts := append([]Option{WithVisitHook(func(channel string, _ int) { channels = append(channels, channel) })}, tc.opts...)
+
+			_, err := New(hClient, opts...).DiscoverReleases(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64", nil)
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to discover releases: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedChannels, channels); diff != "" {
+				t.Errorf("Fetched channels mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiscoverReleasesWithAdditionalChannelPrefixes(t *testing.T) {
+	tests := []struct {
+		name             string
+		opts             []Option
+		expectedChannels []string
+	}{
+		{
+			name:             "only the start channel prefix is followed by default",
+			expectedChannels: []string{"stable-4.16", "stable-4.17"},
+		},
+		{
+			name:             "eus channels are followed once the prefix is added",
+			opts:             []Option{WithAdditionalChannelPrefixes([]string{"eus"})},
+			expectedChannels: []string{"stable-4.16", "eus-4.16", "stable-4.17"},
+		},
+		{
+			name:             "the deny list applies to the additional prefixes",
+			opts:             []Option{WithAdditionalChannelPrefixes([]string{"eus-", "candidate"}), WithChannelDenyList([]string{"candidate-*"})},
+			expectedChannels: []string{"stable-4.16", "eus-4.16", "stable-4.17"},
+		},
+		{
+			name:             "channels are compared with the start channel version when the min version is overridden",
+			opts:             []Option{WithAdditionalChannelPrefixes([]string{"eus"}), WithMinVersion("4.16.5")},
+			expectedChannels: []string{"stable-4.16", "eus-4.16", "stable-4.17"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hClient := fakeHTTPClientForFiles(t, map[string]string{
+				testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-channel-tiers.json",
+				testGraphURL + "?arch=amd64&channel=eus-4.16":    "testdata/discover-releases-stable-4.16.json",
+				testGraphURL + "?arch=amd64&channel=stable-4.17": "testdata/discover-releases-stable-4.17.json",
+			})
+			var channels []string
+			opts := append([]Option{
+				WithMaxVersion("4.17"),
+				WithVisitHook(func(channel string, _ int) { channels = append(channels, channel) }),
+			}, tc.opts...)
+
+			if _, err := New(hClient, opts...).DiscoverReleases(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64", nil); err != nil {
+				t.Fatalf("Failed to discover releases: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedChannels, channels); diff != "" {
+				t.Errorf("Fetched channels mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiscoverReleasesWithSortUpgrades(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected []string
+	}{
+		{
+			name:     "upgrades are sorted by default",
+			expected: []string{"4.16.9", "4.16.10"},
+		},
+		{
+			name:     "upgrades are sorted when the option is enabled",
+			opts:     []Option{WithSortUpgrades()},
+			expected: []string{"4.16.9", "4.16.10"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hClient := fakeHTTPClientForFiles(t, map[string]string{
+				testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-unsorted-edges.json",
+			})
+			releases, err := New(hClient, tc.opts...).DiscoverReleases(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64", nil)
+			if err != nil {
+				t.Fatalf("Failed to discover releases: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, releases["stable-4.16"]["4.16.1"].AvailableUpgrades); diff != "" {
+				t.Errorf("AvailableUpgrades mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSortAllAvailableUpgradesInvalidVersion(t *testing.T) {
+	releases := ReleasesByChannel{
+		"stable-4.16": VersionReleases{
+			"4.16.1": Release{Version: "4.16.1", AvailableUpgrades: []string{"4.16.2", "not-a-version"}},
+		},
+	}
+	err := sortAllAvailableUpgrades(releases)
+	if err == nil {
+		t.Fatal("Expected an error, but got none")
+	}
+	if expected := `error sorting upgrades in channel stable-4.16: 4.16.1: invalid semantic version in AvailableUpgrades[1]="not-a-version"`; !strings.Contains(err.Error(), expected) {
+		t.Errorf("Expected error containing %q, got %q", expected, err.Error())
+	}
+}
+
+func TestFetchGraphWithRateLimit(t *testing.T) {
+	var requests []*http.Request
+	hClient := fakeHTTPClientRecordingRequests(t, "testdata/fetch-graph-valid-response.json", &requests)
+	// a single token refilled every 100ms
+	target := New(hClient, WithRateLimit(rate.Limit(10), 1))
+
+	start := time.Now()
+	for _, channel := range []string{"stable-4.16", "stable-4.17"} {
+		if _, err := target.fetchGraph(rawURLtoURLOrDie(testGraphURL), channel, "amd64"); err != nil {
+			t.Fatalf("fetchGraph returned an error: %v", err)
+		}
+	}
+	if len(requests) != 2 {
+		t.Fatalf("Expected exactly two requests, got %d", len(requests))
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("Expected the second request to be delayed by the rate limit, both were sent within %v", elapsed)
+	}
+}
+
+func TestDiscoverReleasesWithVersionParser(t *testing.T) {
+	stripVendorSuffix := func(v string) (*version.Version, error) {
+		v, _, _ = strings.Cut(v, "_")
+		return version.NewVersion(v)
+	}
+
+	tests := []struct {
+		name          string
+		opts          []Option
+		expected      ReleasesByChannel
+		expectedError string
+	}{
+		{
+			name:          "vendor versions are rejected by default",
+			expectedError: `invalid version "4.16.2_acme"`,
+		},
+		{
+			name: "custom parser strips the vendor suffix",
+			opts: []Option{WithVersionParser(stripVendorSuffix)},
+			expected: ReleasesByChannel{
+				"stable-4.16": VersionReleases{
+					"4.16.1": Release{
+						Version:             "4.16.1",
+						Channel:             "stable-4.16",
+						Arch:                "amd64",
+						Payload:             "payload-4.16.1",
+						AvailableUpgrades:   []string{"4.16.2", "4.16.3"},
+						ConditionalUpgrades: map[string][]Risk{"4.16.3": {{Name: "RiskA"}}},
+					},
+					"4.16.2": Release{Version: "4.16.2", Channel: "stable-4.16", Arch: "amd64", Payload: "payload-4.16.2"},
+					"4.16.3": Release{Version: "4.16.3", Channel: "stable-4.16", Arch: "amd64", Payload: "payload-4.16.3"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hClient := fakeHTTPClientForFiles(t, map[string]string{
+				testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-vendor-versions.json",
+			})
+			releases, err := New(hClient, tc.opts...).DiscoverReleases(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64", []string{AllConditionalEdgeRisks})
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to discover releases: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, releases); diff != "" {
+				t.Errorf("Releases mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiscoverReleasesWithKeepEdgeTargetsBelowMin(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected VersionReleases
+	}{
+		{
+			name: "edges to below-min nodes are dropped by default",
+			expected: VersionReleases{
+				"4.16.1": Release{Version: "4.16.1", Channel: "stable-4.16", Arch: "amd64", Payload: "payload-4.16.1", AvailableUpgrades: []string{"4.16.2"}},
+				"4.16.2": Release{Version: "4.16.2", Channel: "stable-4.16", Arch: "amd64", Payload: "payload-4.16.2"},
+			},
+		},
+		{
+			name: "below-min edge targets are kept and flagged",
+			opts: []Option{WithKeepEdgeTargetsBelowMin()},
+			expected: VersionReleases{
+				"4.15.9": Release{Version: "4.15.9", Channel: "stable-4.16", Arch: "amd64", Payload: "payload-4.15.9", BelowMin: true},
+				"4.16.1": Release{Version: "4.16.1", Channel: "stable-4.16", Arch: "amd64", Payload: "payload-4.16.1", AvailableUpgrades: []string{"4.15.9", "4.16.2"}},
+				"4.16.2": Release{Version: "4.16.2", Channel: "stable-4.16", Arch: "amd64", Payload: "payload-4.16.2"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hClient := fakeHTTPClientForFiles(t, map[string]string{
+				testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-below-min-target.json",
+			})
+			releases, err := New(hClient, tc.opts...).DiscoverReleases(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64", nil)
+			if err != nil {
+				t.Fatalf("Failed to discover releases: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, releases["stable-4.16"]); diff != "" {
+				t.Errorf("Releases mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFetchGraphWithHeader(t *testing.T) {
+	var requests []*http.Request
+	hClient := fakeHTTPClientRecordingRequests(t, "testdata/fetch-graph-valid-response.json", &requests)
+	target := New(hClient,
+		WithHeader("X-Request-ID", "req-1"),
+		WithHeader("X-Org-ID", "org-1"),
+		WithHeader("X-Org-ID", "org-2"),
+		WithHeader("Accept", "text/html"),
+	)
+
+	if _, err := target.fetchGraph(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64"); err != nil {
+		t.Fatalf("fetchGraph returned an error: %v", err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("Expected exactly one request, got %d", len(requests))
+	}
+	expected := map[string][]string{
+		"X-Request-Id": {"req-1"},
+		"X-Org-Id":     {"org-1", "org-2"},
+		"Accept":       {"application/json"},
+	}
+	for header, values := range expected {
+		if diff := cmp.Diff(values, requests[0].Header.Values(header)); diff != "" {
+			t.Errorf("%s header mismatch (-expected +got):\n%s", header, diff)
+		}
+	}
+}
+
+func TestFetchGraphWithClientTrace(t *testing.T) {
+	data, err := os.ReadFile("testdata/fetch-graph-valid-response.json")
+	if err != nil {
+		t.Fatalf("Failed to read test data file: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	var tracedURLs []string
+	firstResponseBytes := 0
+	target := New(server.Client(), WithClientTrace(func(req *http.Request) *httptrace.ClientTrace {
+		tracedURLs = append(tracedURLs, req.URL.String())
+		return &httptrace.ClientTrace{
+			GotFirstResponseByte: func() {
+				firstResponseBytes++
+			},
+		}
+	}))
+
+	if _, err := target.fetchGraph(rawURLtoURLOrDie(server.URL), "stable-4.16", "amd64"); err != nil {
+		t.Fatalf("fetchGraph returned an error: %v", err)
+	}
+	if diff := cmp.Diff([]string{server.URL + "?arch=amd64&channel=stable-4.16"}, tracedURLs); diff != "" {
+		t.Errorf("Traced URLs mismatch (-expected +got):\n%s", diff)
+	}
+	if firstResponseBytes != 1 {
+		t.Errorf("Expected GotFirstResponseByte to be called once, got %d", firstResponseBytes)
+	}
+}
+
+func TestDiscoverReleasesWithExpandMultiArch(t *testing.T) {
+	tests := []struct {
+		name             string
+		opts             []Option
+		expectedArchs    []string
+		expectedChannels []string
+	}{
+		{
+			name:             "single multi-arch fetch by default",
+			expectedArchs:    []string{"multi"},
+			expectedChannels: []string{"stable-4.16"},
+		},
+		{
+			name:             "fetch per arch",
+			opts:             []Option{WithExpandMultiArch()},
+			expectedArchs:    []string{"amd64", "arm64", "ppc64le", "s390x"},
+			expectedChannels: []string{"stable-4.16/amd64", "stable-4.16/arm64", "stable-4.16/ppc64le", "stable-4.16/s390x"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests []*http.Request
+			hClient := fakeHTTPClientRecordingRequests(t, "testdata/discover-releases-stable-4.16.json", &requests)
+
+			releases, err := New(hClient, tc.opts...).DiscoverReleases(nil, "stable-4.16", "multi", nil)
+			if err != nil {
+				t.Fatalf("Failed to discover releases: %v", err)
+			}
+			var archs []string
+			for _, req := range requests {
+				archs = append(archs, req.URL.Query().Get("arch"))
+			}
+			if diff := cmp.Diff(tc.expectedArchs, archs); diff != "" {
+				t.Errorf("Fetched archs mismatch (-expected +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedChannels, releases.SortedChannels()); diff != "" {
+				t.Errorf("Channels mismatch (-expected +got):\n%s", diff)
+			}
+			for channel, versionReleases := range releases {
+				// the arch is the part of the key after the "/", or multi
+				expectedArch := "multi"
+				if _, arch, found := strings.Cut(channel, "/"); found {
+					expectedArch = arch
+				}
+				for _, release := range versionReleases {
+					if release.Arch != expectedArch {
+						t.Errorf("Expected release %s of %s to be tagged with arch %s, got %s", release.Version, channel, expectedArch, release.Arch)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDiscoverReleasesWithExcludeVersions(t *testing.T) {
+	tests := []struct {
+		name             string
+		excludeVersions  []string
+		expectedReleases VersionReleases
+		expectedError    string
+	}{
+		{
+			name:            "middle version excluded",
+			excludeVersions: []string{"4.16.2"},
+			expectedReleases: VersionReleases{
+				"4.16.1": {Version: "4.16.1", Payload: "payload-4.16.1", Arch: "amd64", Channel: "stable-4.16", AvailableUpgrades: []string{"4.16.3"}},
+				"4.16.3": {
+					Version:             "4.16.3",
+					Payload:             "payload-4.16.3",
+					Arch:                "amd64",
+					Channel:             "stable-4.16",
+					AvailableUpgrades:   []string{"4.16.4"},
+					ConditionalUpgrades: map[string][]Risk{"4.16.4": {{Name: "RiskA"}}},
+				},
+				"4.16.4": {Version: "4.16.4", Payload: "payload-4.16.4", Arch: "amd64", Channel: "stable-4.16"},
+			},
+		},
+		{
+			name:            "invalid version",
+			excludeVersions: []string{"not-a-version"},
+			expectedError:   `invalid excluded version "not-a-version"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hClient := fakeHTTPClientForFiles(t, map[string]string{
+				testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-exclude-versions.json",
+			})
+			releases, err := New(hClient, WithExcludeVersions(tc.excludeVersions)).DiscoverReleases(nil, "stable-4.16", "amd64", []string{"RiskA"})
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to discover releases: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedReleases, releases["stable-4.16"]); diff != "" {
+				t.Errorf("Releases mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiscoverReleasesWithMinVersion(t *testing.T) {
+	tests := []struct {
+		name             string
+		minVersion       string
+		expectedVersions []string
+		expectedError    string
+	}{
+		{
+			name:             "override above the start channel version",
+			minVersion:       "4.16.3",
+			expectedVersions: []string{"4.16.3", "4.16.4"},
+		},
+		{
+			name:             "override equal to the start channel version",
+			minVersion:       "4.16",
+			expectedVersions: []string{"4.16.1", "4.16.2", "4.16.3", "4.16.4"},
+		},
+		{
+			name:          "override below the start channel version",
+			minVersion:    "4.15.9",
+			expectedError: `invalid min version "4.15.9": lower than the start channel version 4.16.0`,
+		},
+		{
+			name:          "invalid override",
+			minVersion:    "latest",
+			expectedError: `invalid min version "latest"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hClient := fakeHTTPClientForFiles(t, map[string]string{
+				testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-exclude-versions.json",
+			})
+			releases, err := New(hClient, WithMinVersion(tc.minVersion)).DiscoverReleases(nil, "stable-4.16", "amd64", nil)
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to discover releases: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedVersions, sortedVersions(releases["stable-4.16"])); diff != "" {
+				t.Errorf("Versions mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiscoverReleasesWithRequireNonEmptyStart(t *testing.T) {
+	tests := []struct {
+		name          string
+		inputFile     string
+		opts          []Option
+		expectedError string
+	}{
+		{
+			name:      "empty start channel allowed by default",
+			inputFile: "testdata/discover-releases-stable-4.16-empty.json",
+		},
+		{
+			name:          "empty start channel",
+			inputFile:     "testdata/discover-releases-stable-4.16-empty.json",
+			opts:          []Option{WithRequireNonEmptyStart()},
+			expectedError: "start channel stable-4.16 has no amd64 releases at or above 4.16.0",
+		},
+		{
+			name:          "start channel without releases at or above the min version",
+			inputFile:     "testdata/discover-releases-stable-4.16-exclude-versions.json",
+			opts:          []Option{WithRequireNonEmptyStart(), WithMinVersion("4.16.5")},
+			expectedError: "start channel stable-4.16 has no amd64 releases at or above 4.16.5",
+		},
+		{
+			name:      "non-empty start channel",
+			inputFile: "testdata/discover-releases-stable-4.16-exclude-versions.json",
+			opts:      []Option{WithRequireNonEmptyStart()},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hClient := fakeHTTPClientForFiles(t, map[string]string{
+				testGraphURL + "?arch=amd64&channel=stable-4.16": tc.inputFile,
+			})
+			_, err := New(hClient, tc.opts...).DiscoverReleases(nil, "stable-4.16", "amd64", nil)
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to discover releases: %v", err)
+			}
+		})
+	}
+}
+
+func TestFetchGraphWithRawCapture(t *testing.T) {
+	tests := []struct {
+		name          string
+		inputFile     string
+		expectedError string
+	}{
+		{
+			name:      "valid response",
+			inputFile: "testdata/fetch-graph-valid-response.json",
+		},
+		{
+			name:          "invalid response",
+			inputFile:     "testdata/fetch-graph-invalid-response.json",
+			expectedError: "error parsing JSON",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := os.ReadFile(tc.inputFile)
+			if err != nil {
+				t.Fatalf("Failed to read test data file: %v", err)
+			}
+			var requests []*http.Request
+			capturedURLs := []string{}
+			var capturedBody []byte
+			target := New(fakeHTTPClientRecordingRequests(t, tc.inputFile, &requests), WithRawCapture(func(url string, body []byte) {
+				capturedURLs = append(capturedURLs, url)
+				capturedBody = body
+			}))
+
+			_, err = target.fetchGraph(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64")
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %v", tc.expectedError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("fetchGraph returned an error: %v", err)
+			}
+			if diff := cmp.Diff([]string{testGraphURL + "?arch=amd64&channel=stable-4.16"}, capturedURLs); diff != "" {
+				t.Errorf("Captured URLs mismatch (-expected +got):\n%s", diff)
+			}
+			if !bytes.Equal(data, capturedBody) {
+				t.Errorf("Expected the captured body to be %q, got %q", data, capturedBody)
+			}
+		})
+	}
+}
+
+func TestDiscoverReleasesWithLenientDownstream(t *testing.T) {
+	newHTTPClient := func(t *testing.T) *http.Client {
+		return &http.Client{
+			Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+				files := map[string]string{
+					testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-with-4.17-4.18.json",
+					testGraphURL + "?arch=amd64&channel=stable-4.17": "testdata/discover-releases-stable-4.17.json",
+				}
+				filename, ok := files[req.URL.String()]
+				if !ok {
+					return &http.Response{
+						StatusCode: http.StatusInternalServerError,
+						Body:       io.NopCloser(strings.NewReader("")),
+					}
+				}
+				data, err := os.ReadFile(filename)
+				if err != nil {
+					t.Fatalf("Failed to read file %s: %v", filename, err)
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewReader(data)),
+				}
+			}),
+		}
+	}
+
+	_, err := New(newHTTPClient(t)).DiscoverReleases(nil, "stable-4.16", "amd64", nil)
+	expectedError := "error fetching amd64 graph for channel stable-4.18"
+	if err == nil {
+		t.Fatalf("Expected error containing %q, but got none", expectedError)
+	}
+	if !strings.Contains(err.Error(), expectedError) {
+		t.Errorf("Expected error containing %q, got %q", expectedError, err.Error())
+	}
+
+	releases, unfetched, err := New(newHTTPClient(t), WithLenientDownstream()).DiscoverReleasesWithUnfetchedChannels(nil, "stable-4.16", "amd64", nil)
+	if err != nil {
+		t.Fatalf("Failed to discover releases: %v", err)
+	}
+	if diff := cmp.Diff([]string{"stable-4.16", "stable-4.17"}, releases.SortedChannels()); diff != "" {
+		t.Errorf("Channels mismatch (-expected +got):\n%s", diff)
+	}
+	if len(releases["stable-4.16"]) == 0 {
+		t.Errorf("Expected releases for the start channel stable-4.16")
+	}
+	if len(unfetched) != 1 {
+		t.Fatalf("Expected exactly one unfetched channel, got %v", unfetched)
+	}
+	if unfetched[0].Channel != "stable-4.18" || unfetched[0].StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected stable-4.18 to be unfetched with status %d, got %+v", http.StatusInternalServerError, unfetched[0])
+	}
+	if !strings.Contains(unfetched[0].Reason, "status 500") {
+		t.Errorf("Expected the reason to contain %q, got %q", "status 500", unfetched[0].Reason)
+	}
+}
+
+func TestDiscoverReleasesWithLenientDownstreamFailingStartChannel(t *testing.T) {
+	hClient := &http.Client{
+		Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+			return &http.Response{
+				StatusCode: http.StatusInternalServerError,
+				Body:       io.NopCloser(strings.NewReader("")),
+			}
+		}),
+	}
+
+	_, err := New(hClient, WithLenientDownstream()).DiscoverReleases(nil, "stable-4.16", "amd64", nil)
+	expectedError := "error fetching amd64 graph for channel stable-4.16"
+	if err == nil {
+		t.Fatalf("Expected error containing %q, but got none", expectedError)
+	}
+	if !strings.Contains(err.Error(), expectedError) {
+		t.Errorf("Expected error containing %q, got %q", expectedError, err.Error())
+	}
+}
+
+func TestFetchGraphWithArchParamName(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          []Option
+		expectedURL   string
+		expectedError string
+	}{
+		{
+			name:        "default parameter name",
+			expectedURL: testGraphURL + "?arch=amd64&channel=stable-4.16",
+		},
+		{
+			name:        "architecture parameter name",
+			opts:        []Option{WithArchParamName("architecture")},
+			expectedURL: testGraphURL + "?architecture=amd64&channel=stable-4.16",
+		},
+		{
+			name:          "empty parameter name",
+			opts:          []Option{WithArchParamName("")},
+			expectedError: `invalid arch query parameter name ""`,
+		},
+		{
+			name:          "channel parameter name",
+			opts:          []Option{WithArchParamName("channel")},
+			expectedError: `invalid arch query parameter name "channel"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests []*http.Request
+			hClient := fakeHTTPClientRecordingRequests(t, "testdata/fetch-graph-valid-response.json", &requests)
+
+			_, err := New(hClient, tc.opts...).fetchGraph(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "x86_64")
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetchGraph returned an error: %v", err)
+			}
+			if len(requests) != 1 {
+				t.Fatalf("Expected exactly one request, got %d", len(requests))
+			}
+			if got := requests[0].URL.String(); got != tc.expectedURL {
+				t.Errorf("Expected request URL %q, got %q", tc.expectedURL, got)
+			}
+		})
+	}
+}
+
+func TestDiscoverReleasesRejectsArchParamInGraphURL(t *testing.T) {
+	target := New(&http.Client{}, WithArchParamName("architecture"))
+	_, err := target.DiscoverReleases(rawURLtoURLOrDie(testGraphURL+"?architecture=amd64"), "stable-4.16", "amd64", nil)
+	expectedError := "the architecture query parameter is set by the client"
+	if err == nil {
+		t.Fatalf("Expected error containing %q, but got none", expectedError)
+	}
+	if !strings.Contains(err.Error(), expectedError) {
+		t.Errorf("Expected error containing %q, got %q", expectedError, err.Error())
+	}
+}
+
+// generatedGraph returns the JSON of a stable-4.16 graph with the given number of nodes.
+func generatedGraph(nodes int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`{"nodes":[`)
+	for i := 0; i < nodes; i++ {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		fmt.Fprintf(&buf, `{"version":"4.16.%d","payload":"p-4.16.%d","metadata":{"io.openshift.upgrades.graph.release.channels":"stable-4.16"}}`, i, i)
+	}
+	buf.WriteString(`],"edges":[]}`)
+	return buf.Bytes()
+}
+
+func TestDiscoverReleasesWithMaxNodesPerChannel(t *testing.T) {
+	tests := []struct {
+		name          string
+		nodes         int
+		opts          []Option
+		expectedError string
+	}{
+		{
+			name:  "no limit by default",
+			nodes: 1000,
+		},
+		{
+			name:  "within the limit",
+			nodes: 100,
+			opts:  []Option{WithMaxNodesPerChannel(100)},
+		},
+		{
+			name:          "oversized graph",
+			nodes:         1000,
+			opts:          []Option{WithMaxNodesPerChannel(100)},
+			expectedError: "amd64 graph for channel stable-4.16 has 1000 nodes, more than the limit of 100",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data := generatedGraph(tc.nodes)
+			hClient := &http.Client{
+				Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(bytes.NewReader(data)),
+					}
+				}),
+			}
+
+			releases, err := New(hClient, tc.opts...).DiscoverReleases(nil, "stable-4.16", "amd64", nil)
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to discover releases: %v", err)
+			}
+			if got := len(releases["stable-4.16"]); got != tc.nodes {
+				t.Errorf("Expected %d releases, got %d", tc.nodes, got)
+			}
+		})
+	}
+}
+
+func TestFetchGraphWithMaxResponseBytes(t *testing.T) {
+	const inputFile = "testdata/fetch-graph-valid-response.json"
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		t.Fatalf("Failed to read test data file: %v", err)
+	}
+	tests := []struct {
+		name          string
+		opts          []Option
+		expectedError string
+	}{
+		{
+			name: "default limit",
+		},
+		{
+			name: "body of the size of the limit",
+			opts: []Option{WithMaxResponseBytes(int64(len(data)))},
+		},
+		{
+			name:          "oversized body",
+			opts:          []Option{WithMaxResponseBytes(int64(len(data) - 1))},
+			expectedError: fmt.Sprintf("response exceeds the limit of %d bytes", len(data)-1),
+		},
+		{
+			name: "disabled limit",
+			opts: []Option{WithMaxResponseBytes(0)},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests []*http.Request
+			hClient := fakeHTTPClientRecordingRequests(t, inputFile, &requests)
+
+			_, err := New(hClient, tc.opts...).fetchGraph(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64")
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetchGraph returned an error: %v", err)
+			}
+		})
+	}
+}
+
+func TestFetchGraphWithRequestBuilder(t *testing.T) {
+	var method, contentType, body, requestURL string
+	hClient := &http.Client{
+		Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+			method, contentType, requestURL = req.Method, req.Header.Get("Content-Type"), req.URL.String()
+			data, err := io.ReadAll(req.Body)
+			if err != nil {
+				t.Fatalf("Failed to read the request body: %v", err)
+			}
+			body = string(data)
+			data, err = os.ReadFile("testdata/fetch-graph-valid-response.json")
+			if err != nil {
+				t.Fatalf("Failed to read test data file: %v", err)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(data)),
+			}
+		}),
+	}
+	builder := func(base *url.URL, channel, arch string) (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, base.String(), strings.NewReader(fmt.Sprintf(`{"channel":%q,"arch":%q}`, channel, arch)))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	}
+
+	graph, err := New(hClient, WithRequestBuilder(builder)).fetchGraph(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "x86_64")
+	if err != nil {
+		t.Fatalf("fetchGraph returned an error: %v", err)
+	}
+	if method != http.MethodPost {
+		t.Errorf("Expected a %s request, got %s", http.MethodPost, method)
+	}
+	if requestURL != testGraphURL {
+		t.Errorf("Expected request URL %q, got %q", testGraphURL, requestURL)
+	}
+	if contentType != "application/json" {
+		t.Errorf("Expected Content-Type %q, got %q", "application/json", contentType)
+	}
+	if expectedBody := `{"channel":"stable-4.16","arch":"amd64"}`; body != expectedBody {
+		t.Errorf("Expected request body %q, got %q", expectedBody, body)
+	}
+	if len(graph.Nodes) == 0 {
+		t.Errorf("Expected the graph to have nodes")
+	}
+
+	failing := func(*url.URL, string, string) (*http.Request, error) {
+		return nil, fmt.Errorf("no cluster id")
+	}
+	_, err = New(hClient, WithRequestBuilder(failing)).fetchGraph(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64")
+	expectedError := "error creating request for amd64 graph for channel stable-4.16: no cluster id"
+	if err == nil {
+		t.Fatalf("Expected error containing %q, but got none", expectedError)
+	}
+	if !strings.Contains(err.Error(), expectedError) {
+		t.Errorf("Expected error containing %q, got %q", expectedError, err.Error())
+	}
+
+	nilBuilder := func(*url.URL, string, string) (*http.Request, error) {
+		return nil, nil
+	}
+	_, err = New(hClient, WithRequestBuilder(nilBuilder)).fetchGraph(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64")
+	expectedError = "request builder returned a nil request for channel stable-4.16"
+	if err == nil {
+		t.Fatalf("Expected error containing %q, but got none", expectedError)
+	}
+	if !strings.Contains(err.Error(), expectedError) {
+		t.Errorf("Expected error containing %q, got %q", expectedError, err.Error())
+	}
+	err = New(hClient, WithRequestBuilder(nilBuilder)).Ping(nil, "", "")
+	if err == nil || !strings.Contains(err.Error(), "request builder returned a nil request") {
+		t.Errorf("Expected Ping to report the nil request, got %v", err)
+	}
+}
+
+func TestDiscoverReleasesWithSkipInvalidEdges(t *testing.T) {
+	hClient := fakeHTTPClientForFiles(t, map[string]string{
+		testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-invalid-edge.json",
+	})
+
+	_, err := New(hClient).DiscoverReleases(nil, "stable-4.16", "amd64", nil)
+	expectedError := "invalid edge indices: [1 7] at index: 1"
+	if err == nil {
+		t.Fatalf("Expected error containing %q, but got none", expectedError)
+	}
+	if !strings.Contains(err.Error(), expectedError) {
+		t.Errorf("Expected error containing %q, got %q", expectedError, err.Error())
+	}
+
+	logger := &capturingLogger{}
+	releases, err := New(hClient, WithSkipInvalidEdges(), WithLogger(logger)).DiscoverReleases(nil, "stable-4.16", "amd64", nil)
+	if err != nil {
+		t.Fatalf("Failed to discover releases: %v", err)
+	}
+	expectedUpgrades := map[string][]string{
+		"4.16.1": {"4.16.2", "4.16.3"},
+		"4.16.2": {"4.16.3"},
+		"4.16.3": nil,
+	}
+	gotUpgrades := make(map[string][]string)
+	for ver, release := range releases["stable-4.16"] {
+		gotUpgrades[ver] = release.AvailableUpgrades
+	}
+	if diff := cmp.Diff(expectedUpgrades, gotUpgrades); diff != "" {
+		t.Errorf("Available upgrades mismatch (-expected +got):\n%s", diff)
+	}
+	expectedWarning := logLine{Level: "warn", Msg: "dropping edge", KeysAndValues: []any{"edge", []int{1, 7}, "index", 1, "reason", "invalid edge indices"}}
+	var warnings []logLine
+	for _, line := range logger.lines {
+		if line.Level == "warn" {
+			warnings = append(warnings, line)
+		}
+	}
+	if diff := cmp.Diff([]logLine{expectedWarning}, warnings); diff != "" {
+		t.Errorf("Warnings mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestDiscoverReleasesWithIncludeBelowMinInStart(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected ReleasesByChannel
+	}{
+		{
+			name: "below-min releases dropped by default",
+			expected: ReleasesByChannel{
+				"stable-4.16": {
+					"4.16.1": {Version: "4.16.1", Channel: "stable-4.16", Arch: "amd64", Payload: "payload-4.16.1", Metadata: map[string]string{releaseChannelsMetadataKey: "stable-4.16,stable-4.17"}},
+				},
+				"stable-4.17": {
+					"4.17.0": {Version: "4.17.0", Channel: "stable-4.17", Arch: "amd64", Payload: "payload-4.17.0", Metadata: map[string]string{releaseChannelsMetadataKey: "stable-4.17"}},
+				},
+			},
+		},
+		{
+			name: "below-min releases kept only in the start channel",
+			opts: []Option{WithIncludeBelowMinInStart()},
+			expected: ReleasesByChannel{
+				"stable-4.16": {
+					"4.15.9": {Version: "4.15.9", Channel: "stable-4.16", Arch: "amd64", Payload: "payload-4.15.9", AvailableUpgrades: []string{"4.16.1"}, Metadata: map[string]string{releaseChannelsMetadataKey: "stable-4.15,stable-4.16"}, BelowMin: true},
+					"4.16.1": {Version: "4.16.1", Channel: "stable-4.16", Arch: "amd64", Payload: "payload-4.16.1", Metadata: map[string]string{releaseChannelsMetadataKey: "stable-4.16,stable-4.17"}},
+				},
+				"stable-4.17": {
+					"4.17.0": {Version: "4.17.0", Channel: "stable-4.17", Arch: "amd64", Payload: "payload-4.17.0", Metadata: map[string]string{releaseChannelsMetadataKey: "stable-4.17"}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hClient := fakeHTTPClientForFiles(t, map[string]string{
+				testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-logging.json",
+				testGraphURL + "?arch=amd64&channel=stable-4.17": "testdata/discover-releases-stable-4.17-below-min.json",
+			})
+			releases, err := New(hClient, tc.opts...).DiscoverReleases(nil, "stable-4.16", "amd64", nil)
+			if err != nil {
+				t.Fatalf("Failed to discover releases: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, releases); diff != "" {
+				t.Errorf("Releases mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package cincinnaticlient
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// channelReleases is the serialized form of a single channel used by WriteJSON.
+type channelReleases struct {
+	Channel  string    `json:"channel"`
+	Releases []Release `json:"releases"`
+}
+
+// WriteJSON writes the releases to w as a JSON document.
+// Channels are sorted by name, releases and their AvailableUpgrades
+// in ascending semantic-version order, so that the output is stable.
+func (r ReleasesByChannel) WriteJSON(w io.Writer) error {
+	channels := make([]channelReleases, 0, len(r))
+	for _, channel := range r.SortedChannels() {
+		cr := channelReleases{Channel: channel, Releases: []Release{}}
+		for _, ver := range sortedVersions(r[channel]) {
+			cr.Releases = append(cr.Releases, r[channel][ver].withSortedUpgrades())
+		}
+		channels = append(channels, cr)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(channels)
+}
+
+// Encode writes the releases to w as a JSON document that can be read back with DecodeReleasesByChannel.
+// It uses the layout of WriteJSON, with channels sorted by name and releases in ascending
+// semantic-version order, but keeps the order of the AvailableUpgrades so that decoding
+// reconstructs the releases exactly.
+func (r ReleasesByChannel) Encode(w io.Writer) error {
+	channels := make([]channelReleases, 0, len(r))
+	for _, channel := range r.SortedChannels() {
+		cr := channelReleases{Channel: channel, Releases: []Release{}}
+		for _, ver := range sortedVersions(r[channel]) {
+			cr.Releases = append(cr.Releases, r[channel][ver])
+		}
+		channels = append(channels, cr)
+	}
+	return json.NewEncoder(w).Encode(channels)
+}
+
+// DecodeReleasesByChannel reads the releases written by Encode or WriteJSON from r.
+// Releases are keyed by their Version.
+func DecodeReleasesByChannel(r io.Reader) (ReleasesByChannel, error) {
+	var channels []channelReleases
+	if err := json.NewDecoder(r).Decode(&channels); err != nil {
+		return nil, fmt.Errorf("error decoding releases: %w", err)
+	}
+	releasesByChannel := make(ReleasesByChannel, len(channels))
+	for _, cr := range channels {
+		if _, ok := releasesByChannel[cr.Channel]; ok {
+			return nil, fmt.Errorf("error decoding releases: duplicate channel %q", cr.Channel)
+		}
+		releases := make(VersionReleases, len(cr.Releases))
+		for _, release := range cr.Releases {
+			releases[release.Version] = release
+		}
+		releasesByChannel[cr.Channel] = releases
+	}
+	return releasesByChannel, nil
+}
+
+// withSortedUpgrades returns a copy of the release whose AvailableUpgrades
+// are sorted in ascending semantic-version order.
+func (r Release) withSortedUpgrades() Release {
+	r.AvailableUpgrades = slices.Clone(r.AvailableUpgrades)
+	SortVersions(r.AvailableUpgrades)
+	return r
+}
+
+// WriteDOT writes the releases to w as a Graphviz digraph.
+// Nodes are versions and edges are AvailableUpgrades, merged across all channels.
+// Edges that come from accepted conditional edges in every channel having them are dashed.
+// Node and edge declarations are sorted so that the output is diffable.
+func (r ReleasesByChannel) WriteDOT(w io.Writer) error {
+	nodes := map[string]bool{}
+	// edges maps from -> to -> whether the edge is conditional
+	edges := map[string]map[string]bool{}
+	for _, releases := range r {
+		for ver, release := range releases {
+			nodes[ver] = true
+			for _, up := range release.AvailableUpgrades {
+				nodes[up] = true
+				if edges[ver] == nil {
+					edges[ver] = map[string]bool{}
+				}
+				// an edge is only conditional if it is conditional in every channel having it
+				_, conditional := release.ConditionalUpgrades[up]
+				if seenConditional, seen := edges[ver][up]; seen {
+					conditional = conditional && seenConditional
+				}
+				edges[ver][up] = conditional
+			}
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("digraph upgrades {\n")
+	sortedNodes := slices.Collect(maps.Keys(nodes))
+	SortVersions(sortedNodes)
+	for _, node := range sortedNodes {
+		fmt.Fprintf(&sb, "  %q;\n", node)
+	}
+	for _, from := range sortedNodes {
+		targets := slices.Collect(maps.Keys(edges[from]))
+		SortVersions(targets)
+		for _, to := range targets {
+			if edges[from][to] {
+				fmt.Fprintf(&sb, "  %q -> %q [style=dashed];\n", from, to)
+				continue
+			}
+			fmt.Fprintf(&sb, "  %q -> %q;\n", from, to)
+		}
+	}
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// csvHeader is the column schema emitted by WriteCSV.
+var csvHeader = []string{"channel", "version", "arch", "payload", "available_upgrades"}
+
+// WriteCSV writes the releases to w as CSV using the csvHeader columns.
+// AvailableUpgrades are sorted and joined with a semicolon.
+// Rows are sorted by channel, then by semantic version.
+func (r ReleasesByChannel) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, channel := range r.SortedChannels() {
+		for _, ver := range sortedVersions(r[channel]) {
+			release := r[channel][ver].withSortedUpgrades()
+			row := []string{channel, release.Version, release.Arch, release.Payload, strings.Join(release.AvailableUpgrades, ";")}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ndjsonRelease is the serialized form of a single release used by WriteNDJSON.
+type ndjsonRelease struct {
+	Channel           string   `json:"channel"`
+	Version           string   `json:"version"`
+	Arch              string   `json:"arch"`
+	Payload           string   `json:"payload"`
+	AvailableUpgrades []string `json:"available_upgrades"`
+}
+
+// WriteNDJSON writes the releases to w as JSON Lines, one object per release.
+// Every release is written as soon as it is encoded, so that the output can be streamed.
+// AvailableUpgrades are sorted, rows are sorted by channel, then by semantic version.
+func (r ReleasesByChannel) WriteNDJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, channel := range r.SortedChannels() {
+		for _, ver := range sortedVersions(r[channel]) {
+			release := r[channel][ver].withSortedUpgrades()
+			row := ndjsonRelease{
+				Channel:           channel,
+				Version:           release.Version,
+				Arch:              release.Arch,
+				Payload:           release.Payload,
+				AvailableUpgrades: release.AvailableUpgrades,
+			}
+			if row.AvailableUpgrades == nil {
+				row.AvailableUpgrades = []string{}
+			}
+			if err := enc.Encode(row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
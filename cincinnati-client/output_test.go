@@ -0,0 +1,274 @@
+package cincinnaticlient
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteJSON(t *testing.T) {
+	releases := ReleasesByChannel{
+		"stable-4.17": VersionReleases{
+			"4.17.1": Release{
+				Version: "4.17.1",
+				Channel: "stable-4.17",
+				Arch:    "amd64",
+				Payload: "payload-4.17.1",
+			},
+		},
+		"stable-4.16": VersionReleases{
+			"4.16.10": Release{
+				Version: "4.16.10",
+				Channel: "stable-4.16",
+				Arch:    "amd64",
+				Payload: "payload-4.16.10",
+			},
+			"4.16.9": Release{
+				Version:           "4.16.9",
+				Channel:           "stable-4.16",
+				Arch:              "amd64",
+				Payload:           "payload-4.16.9",
+				AvailableUpgrades: []string{"4.17.1", "4.16.10"},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := releases.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON returned an error: %v", err)
+	}
+
+	expected, err := os.ReadFile("testdata/write-json-golden.json")
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	if diff := cmp.Diff(string(expected), buf.String()); diff != "" {
+		t.Errorf("JSON output mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	releases := ReleasesByChannel{
+		"stable-4.16": VersionReleases{
+			"4.16.9": Release{
+				Version:             "4.16.9",
+				Channel:             "stable-4.16",
+				Arch:                "amd64",
+				Payload:             "payload-4.16.9",
+				AvailableUpgrades:   []string{"4.17.1", "4.16.10"},
+				ConditionalUpgrades: map[string][]Risk{"4.17.1": {{Name: "RiskA", URL: "https://example.com/risk-a"}}},
+				Metadata:            map[string]string{"io.openshift.upgrades.graph.release.channels": "stable-4.16,stable-4.17"},
+			},
+			"4.16.10": Release{
+				Version: "4.16.10",
+				Channel: "stable-4.16",
+				Arch:    "amd64",
+				Payload: "payload-4.16.10",
+			},
+		},
+		"stable-4.17": VersionReleases{},
+	}
+
+	var buf bytes.Buffer
+	if err := releases.Encode(&buf); err != nil {
+		t.Fatalf("Encode returned an error: %v", err)
+	}
+	decoded, err := DecodeReleasesByChannel(&buf)
+	if err != nil {
+		t.Fatalf("DecodeReleasesByChannel returned an error: %v", err)
+	}
+	if diff := cmp.Diff(releases, decoded); diff != "" {
+		t.Errorf("Round-trip mismatch (-expected +got):\n%s", diff)
+	}
+
+	var again bytes.Buffer
+	if err := decoded.Encode(&again); err != nil {
+		t.Fatalf("Encode returned an error: %v", err)
+	}
+	var first bytes.Buffer
+	if err := releases.Encode(&first); err != nil {
+		t.Fatalf("Encode returned an error: %v", err)
+	}
+	if diff := cmp.Diff(first.String(), again.String()); diff != "" {
+		t.Errorf("Encoding is not stable (-expected +got):\n%s", diff)
+	}
+}
+
+func TestDecodeReleasesByChannelDuplicateChannel(t *testing.T) {
+	_, err := DecodeReleasesByChannel(bytes.NewBufferString(`[{"channel":"stable-4.16","releases":[]},{"channel":"stable-4.16","releases":[]}]`))
+	if err == nil {
+		t.Fatal("Expected an error, but got none")
+	}
+	if expected := `duplicate channel "stable-4.16"`; !strings.Contains(err.Error(), expected) {
+		t.Errorf("Expected error containing %q, got %q", expected, err.Error())
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	releases := ReleasesByChannel{
+		"stable-4.16": VersionReleases{
+			"4.16.1": Release{
+				Version:           "4.16.1",
+				Arch:              "amd64",
+				Payload:           "payload-4.16.1",
+				AvailableUpgrades: []string{"4.16.2"},
+			},
+			"4.16.2": Release{
+				Version:             "4.16.2",
+				Arch:                "amd64",
+				Payload:             "payload-4.16.2",
+				AvailableUpgrades:   []string{"4.16.3"},
+				ConditionalUpgrades: map[string][]Risk{"4.16.3": {{Name: "RiskA"}}, "4.16.4": {{Name: "RiskB"}}},
+			},
+			"4.16.3": Release{
+				Version: "4.16.3",
+				Arch:    "amd64",
+				Payload: "payload-4.16.3",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := releases.WriteDOT(&buf); err != nil {
+		t.Fatalf("WriteDOT returned an error: %v", err)
+	}
+
+	expected, err := os.ReadFile("testdata/write-dot-golden.dot")
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	if diff := cmp.Diff(string(expected), buf.String()); diff != "" {
+		t.Errorf("DOT output mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestWriteDOTMixedConditionalEdge(t *testing.T) {
+	releases := ReleasesByChannel{
+		"fast-4.16": VersionReleases{
+			"4.16.1": Release{
+				Version:             "4.16.1",
+				AvailableUpgrades:   []string{"4.16.2"},
+				ConditionalUpgrades: map[string][]Risk{"4.16.2": {{Name: "RiskA"}}},
+			},
+			"4.16.2": Release{
+				Version:             "4.16.2",
+				AvailableUpgrades:   []string{"4.16.3"},
+				ConditionalUpgrades: map[string][]Risk{"4.16.3": {{Name: "RiskB"}}},
+			},
+			"4.16.3": Release{Version: "4.16.3"},
+		},
+		"stable-4.16": VersionReleases{
+			"4.16.1": Release{
+				Version:           "4.16.1",
+				AvailableUpgrades: []string{"4.16.2"},
+			},
+			"4.16.2": Release{
+				Version:             "4.16.2",
+				AvailableUpgrades:   []string{"4.16.3"},
+				ConditionalUpgrades: map[string][]Risk{"4.16.3": {{Name: "RiskB"}}},
+			},
+			"4.16.3": Release{Version: "4.16.3"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := releases.WriteDOT(&buf); err != nil {
+		t.Fatalf("WriteDOT returned an error: %v", err)
+	}
+
+	expected, err := os.ReadFile("testdata/write-dot-mixed-conditional-golden.dot")
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	if diff := cmp.Diff(string(expected), buf.String()); diff != "" {
+		t.Errorf("DOT output mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	releases := ReleasesByChannel{
+		"stable-4.17": VersionReleases{
+			"4.17.1": Release{
+				Version: "4.17.1",
+				Arch:    "amd64",
+				Payload: "payload-4.17.1",
+			},
+		},
+		"stable-4.16": VersionReleases{
+			"4.16.10": Release{
+				Version:           "4.16.10",
+				Arch:              "amd64",
+				Payload:           "payload-4.16.10",
+				AvailableUpgrades: []string{"4.17.1"},
+			},
+			"4.16.9": Release{
+				Version:           "4.16.9",
+				Arch:              "amd64",
+				Payload:           "payload-4.16.9",
+				AvailableUpgrades: []string{"4.17.1", "4.16.10"},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := releases.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV returned an error: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse the emitted CSV: %v", err)
+	}
+	expected := [][]string{
+		{"channel", "version", "arch", "payload", "available_upgrades"},
+		{"stable-4.16", "4.16.9", "amd64", "payload-4.16.9", "4.16.10;4.17.1"},
+		{"stable-4.16", "4.16.10", "amd64", "payload-4.16.10", "4.17.1"},
+		{"stable-4.17", "4.17.1", "amd64", "payload-4.17.1", ""},
+	}
+	if diff := cmp.Diff(expected, records); diff != "" {
+		t.Errorf("CSV output mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	releases := ReleasesByChannel{
+		"stable-4.17": VersionReleases{
+			"4.17.1": Release{Version: "4.17.1", Arch: "amd64", Payload: "payload-4.17.1"},
+		},
+		"stable-4.16": VersionReleases{
+			"4.16.10": Release{Version: "4.16.10", Arch: "amd64", Payload: "payload-4.16.10", AvailableUpgrades: []string{"4.17.1"}},
+			"4.16.9":  Release{Version: "4.16.9", Arch: "amd64", Payload: "payload-4.16.9", AvailableUpgrades: []string{"4.17.1", "4.16.10"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := releases.WriteNDJSON(&buf); err != nil {
+		t.Fatalf("WriteNDJSON returned an error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a line per release, got %d lines:\n%s", len(lines), buf.String())
+	}
+	var got []map[string]any
+	for _, line := range lines {
+		var row map[string]any
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			t.Fatalf("Failed to parse line %q: %v", line, err)
+		}
+		got = append(got, row)
+	}
+	expected := []map[string]any{
+		{"channel": "stable-4.16", "version": "4.16.9", "arch": "amd64", "payload": "payload-4.16.9", "available_upgrades": []any{"4.16.10", "4.17.1"}},
+		{"channel": "stable-4.16", "version": "4.16.10", "arch": "amd64", "payload": "payload-4.16.10", "available_upgrades": []any{"4.17.1"}},
+		{"channel": "stable-4.17", "version": "4.17.1", "arch": "amd64", "payload": "payload-4.17.1", "available_upgrades": []any{}},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("NDJSON rows mismatch (-expected +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,67 @@
+package cincinnaticlient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Default channel and architecture requested by Ping when the caller doesn't pick them.
+const (
+	DefaultPingChannel = "stable-4.16"
+	DefaultPingArch    = "amd64"
+)
+
+// Ping checks that the graph endpoint is reachable and accepts the client's credentials
+// by requesting the given channel and architecture. The cache is bypassed.
+// If graphURL is nil, the client's graph URL is used. An empty channel or arch defaults
+// to DefaultPingChannel and DefaultPingArch, the arch is normalized with NormalizeArch.
+// The channel should be one the graph is known to serve since a missing channel can't be
+// told apart from a wrong graph URL.
+//
+// It returns nil if the server responds with 200 OK.
+// It returns an *AuthError if the server responds with 401 Unauthorized or 403 Forbidden,
+// a *StatusError for any other status, including 404 Not Found, and a *NetworkError
+// if the request couldn't be sent or the response couldn't be received.
+func (c *Client) Ping(graphURL *url.URL, channel, arch string) error {
+	if graphURL == nil {
+		graphURL = c.graphURL
+	}
+	if channel == "" {
+		channel = DefaultPingChannel
+	}
+	if arch == "" {
+		arch = DefaultPingArch
+	}
+	arch, err := NormalizeArch(arch)
+	if err != nil {
+		return err
+	}
+	if err := validateGraphURL(graphURL, c.archParamName); err != nil {
+		return err
+	}
+	if c.transportErr != nil {
+		return c.transportErr
+	}
+	u, err := c.graphRequestURL(graphURL, channel, arch)
+	if err != nil {
+		return err
+	}
+	req, err := c.buildGraphRequest(context.Background(), graphURL, u, channel, arch)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return &NetworkError{URL: u.String(), Err: err}
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return &AuthError{StatusCode: resp.StatusCode, URL: u.String()}
+	default:
+		return &StatusError{StatusCode: resp.StatusCode, URL: u.String()}
+	}
+}
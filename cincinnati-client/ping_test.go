@@ -0,0 +1,140 @@
+package cincinnaticlient
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	tests := []struct {
+		name           string
+		channel        string
+		arch           string
+		statusCode     int
+		expectedQuery  string
+		expectAuthErr  bool
+		expectedError  string
+		expectedStatus int
+	}{
+		{
+			name:          "200 is a success",
+			statusCode:    http.StatusOK,
+			expectedQuery: "arch=amd64&channel=stable-4.16",
+		},
+		{
+			name:          "the channel and arch picked by the caller are requested",
+			channel:       "fast-4.18",
+			arch:          "aarch64",
+			statusCode:    http.StatusOK,
+			expectedQuery: "arch=arm64&channel=fast-4.18",
+		},
+		{
+			name:           "404 is surfaced as a status error",
+			statusCode:     http.StatusNotFound,
+			expectedQuery:  "arch=amd64&channel=stable-4.16",
+			expectedError:  "error: status 404 when fetching data from",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "401 is surfaced as an auth error",
+			statusCode:     http.StatusUnauthorized,
+			expectAuthErr:  true,
+			expectedQuery:  "arch=amd64&channel=stable-4.16",
+			expectedError:  "authentication failed with status 401",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "500 is surfaced as a status error",
+			statusCode:     http.StatusInternalServerError,
+			expectedQuery:  "arch=amd64&channel=stable-4.16",
+			expectedError:  "error: status 500 when fetching data from",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests []*http.Request
+			hClient := &http.Client{
+				Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+					requests = append(requests, req)
+					return &http.Response{
+						StatusCode: tc.statusCode,
+						Body:       io.NopCloser(strings.NewReader("{}")),
+					}
+				}),
+			}
+
+			err := New(hClient, WithBearerToken("secret-token")).Ping(nil, tc.channel, tc.arch)
+			if len(requests) != 1 {
+				t.Fatalf("Expected exactly one request, got %d", len(requests))
+			}
+			if got := requests[0].URL.RawQuery; got != tc.expectedQuery {
+				t.Errorf("Expected query %q, got %q", tc.expectedQuery, got)
+			}
+			if got := requests[0].Header.Get("Authorization"); got != "Bearer secret-token" {
+				t.Errorf("Expected Authorization header %q, got %q", "Bearer secret-token", got)
+			}
+			if tc.expectedError == "" {
+				if err != nil {
+					t.Fatalf("Ping returned an error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+			}
+			if !strings.Contains(err.Error(), tc.expectedError) {
+				t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+			}
+			var authErr *AuthError
+			if got := errors.As(err, &authErr); got != tc.expectAuthErr {
+				t.Errorf("Expected errors.As(err, *AuthError) to be %v, got %v", tc.expectAuthErr, got)
+			}
+			if !tc.expectAuthErr {
+				var statusErr *StatusError
+				if !errors.As(err, &statusErr) {
+					t.Fatalf("Expected a *StatusError, got %T", err)
+				}
+				if statusErr.StatusCode != tc.expectedStatus {
+					t.Errorf("Expected status %d, got %d", tc.expectedStatus, statusErr.StatusCode)
+				}
+			}
+		})
+	}
+}
+
+func TestPingNetworkError(t *testing.T) {
+	transportErr := errors.New("connection refused")
+	hClient := &http.Client{
+		Transport: roundTripErrFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, transportErr
+		}),
+	}
+
+	err := New(hClient).Ping(nil, "", "")
+	var networkErr *NetworkError
+	if !errors.As(err, &networkErr) {
+		t.Fatalf("Expected a *NetworkError, got %T: %v", err, err)
+	}
+	if !errors.Is(err, transportErr) {
+		t.Errorf("Expected the error to wrap %v, got %v", transportErr, err)
+	}
+	if expectedURL := testGraphURL + "?arch=amd64&channel=stable-4.16"; networkErr.URL != expectedURL {
+		t.Errorf("Expected URL %q, got %q", expectedURL, networkErr.URL)
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		t.Errorf("Expected a network error not to be a *StatusError")
+	}
+}
+
+// roundTripErrFunc is like RoundTripFunc but can fail the request.
+type roundTripErrFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripErrFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
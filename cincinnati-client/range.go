@@ -0,0 +1,91 @@
+package cincinnaticlient
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DiscoverReleasesRange runs a discovery from every channel of the given prefix between
+// the minMinor and maxMinor versions, e.g. from stable-4.12 through stable-4.18 for
+// the "stable" prefix and the "4.12" and "4.18" minors, and merges the results.
+// The minors must share the same major version. Start channels the server doesn't know
+// about (404 Not Found), e.g. because they don't exist yet, are skipped, while a channel discovered
+// from them that is not found fails the discovery like in DiscoverReleases.
+//
+// A channel discovered from several start channels is taken from the discovery of the lowest one,
+// as it also holds the releases below the minVersion of the higher ones.
+func (c *Client) DiscoverReleasesRange(graphURL *url.URL, prefix string, minMinor, maxMinor string, arch string, allowedConditionalEdgeRisks []string) (ReleasesByChannel, error) {
+	channels, err := rangeChannels(prefix, minMinor, maxMinor)
+	if err != nil {
+		return nil, err
+	}
+	merged := make(ReleasesByChannel)
+	for _, startChannel := range channels {
+		releases, err := c.DiscoverReleases(graphURL, startChannel, arch, allowedConditionalEdgeRisks)
+		if startChannelNotFound(err, startChannel) {
+			c.logger.Warn("skipping start channel", "channel", startChannel, "reason", "channel not found")
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for channel, versionReleases := range releases {
+			if _, ok := merged[channel]; !ok {
+				merged[channel] = versionReleases
+			}
+		}
+	}
+	return merged, nil
+}
+
+// startChannelNotFound checks if err reports that the graph of the startChannel itself,
+// not of a channel discovered from it, was not found.
+func startChannelNotFound(err error, startChannel string) bool {
+	var fetchErr *channelFetchError
+	if !errors.As(err, &fetchErr) || fetchErr.channel != startChannel {
+		return false
+	}
+	_, notFound := channelNotFound(err)
+	return notFound
+}
+
+// rangeChannels returns the names of the channels of the given prefix from the minMinor to the maxMinor version.
+func rangeChannels(prefix, minMinor, maxMinor string) ([]string, error) {
+	prefix = strings.TrimSuffix(prefix, "-")
+	if prefix == "" {
+		return nil, fmt.Errorf("channel prefix is required")
+	}
+	minMajor, minMinorNumber, err := parseMinor(minMinor)
+	if err != nil {
+		return nil, err
+	}
+	maxMajor, maxMinorNumber, err := parseMinor(maxMinor)
+	if err != nil {
+		return nil, err
+	}
+	if minMajor != maxMajor {
+		return nil, fmt.Errorf("invalid minor range %s-%s: the major versions differ", minMinor, maxMinor)
+	}
+	if minMinorNumber > maxMinorNumber {
+		return nil, fmt.Errorf("invalid minor range %s-%s: %s is greater than %s", minMinor, maxMinor, minMinor, maxMinor)
+	}
+	var channels []string
+	for minor := minMinorNumber; minor <= maxMinorNumber; minor++ {
+		channels = append(channels, fmt.Sprintf("%s-%d.%d", prefix, minMajor, minor))
+	}
+	return channels, nil
+}
+
+// parseMinor parses a minor version in the <major>.<minor> format, e.g. 4.16.
+func parseMinor(minor string) (int, int, error) {
+	rawMajor, rawMinor, found := strings.Cut(minor, ".")
+	major, majorErr := strconv.Atoi(rawMajor)
+	minorNumber, minorErr := strconv.Atoi(rawMinor)
+	if !found || majorErr != nil || minorErr != nil || major < 0 || minorNumber < 0 {
+		return 0, 0, fmt.Errorf("invalid minor version %q: expected the <major>.<minor> format, e.g. 4.16", minor)
+	}
+	return major, minorNumber, nil
+}
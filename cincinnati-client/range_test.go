@@ -0,0 +1,143 @@
+package cincinnaticlient
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiscoverReleasesRange(t *testing.T) {
+	files := map[string]string{
+		testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16.json",
+		testGraphURL + "?arch=amd64&channel=stable-4.18": "testdata/discover-releases-stable-4.18.json",
+	}
+	var requested []string
+	hClient := &http.Client{
+		Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+			requested = append(requested, req.URL.Query().Get("channel"))
+			filename, ok := files[req.URL.String()]
+			if !ok {
+				return &http.Response{
+					StatusCode: http.StatusNotFound,
+					Body:       io.NopCloser(strings.NewReader("")),
+				}
+			}
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatalf("Failed to read file %s: %v", filename, err)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(data)),
+			}
+		}),
+	}
+
+	releases, err := New(hClient).DiscoverReleasesRange(nil, "stable", "4.16", "4.18", "amd64", nil)
+	if err != nil {
+		t.Fatalf("Failed to discover releases: %v", err)
+	}
+	if diff := cmp.Diff([]string{"stable-4.16", "stable-4.17", "stable-4.18"}, requested); diff != "" {
+		t.Errorf("Requested channels mismatch (-expected +got):\n%s", diff)
+	}
+	expected := ReleasesByChannel{
+		"stable-4.16": {
+			"4.16.2": {Version: "4.16.2", Payload: "payload-stable", Arch: "amd64", Channel: "stable-4.16", Metadata: map[string]string{releaseChannelsMetadataKey: "stable-4.16,fast-4.16"}},
+		},
+		"stable-4.18": {
+			"4.18.1": {Version: "4.18.1", Payload: "payload-4.18", Arch: "amd64", Channel: "stable-4.18"},
+		},
+	}
+	if diff := cmp.Diff(expected, releases); diff != "" {
+		t.Errorf("Releases mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestDiscoverReleasesRangeDownstreamNotFound(t *testing.T) {
+	hClient := fakeHTTPClientForFilesOr404(t, map[string]string{
+		testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-with-4.17-4.18.json",
+		testGraphURL + "?arch=amd64&channel=stable-4.17": "testdata/discover-releases-stable-4.17.json",
+	})
+
+	_, err := New(hClient).DiscoverReleasesRange(nil, "stable", "4.16", "4.16", "amd64", nil)
+	expectedError := "error fetching amd64 graph for channel stable-4.18"
+	if err == nil {
+		t.Fatalf("Expected error containing %q, but got none", expectedError)
+	}
+	if !strings.Contains(err.Error(), expectedError) {
+		t.Errorf("Expected error containing %q, got %q", expectedError, err.Error())
+	}
+}
+
+func TestRangeChannels(t *testing.T) {
+	tests := []struct {
+		name          string
+		prefix        string
+		minMinor      string
+		maxMinor      string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "range",
+			prefix:   "stable",
+			minMinor: "4.12",
+			maxMinor: "4.14",
+			expected: []string{"stable-4.12", "stable-4.13", "stable-4.14"},
+		},
+		{
+			name:     "prefix with a trailing hyphen and a single minor",
+			prefix:   "eus-",
+			minMinor: "4.16",
+			maxMinor: "4.16",
+			expected: []string{"eus-4.16"},
+		},
+		{
+			name:          "invalid minor",
+			prefix:        "stable",
+			minMinor:      "4",
+			maxMinor:      "4.14",
+			expectedError: `invalid minor version "4": expected the <major>.<minor> format, e.g. 4.16`,
+		},
+		{
+			name:          "different majors",
+			prefix:        "stable",
+			minMinor:      "4.18",
+			maxMinor:      "5.0",
+			expectedError: "the major versions differ",
+		},
+		{
+			name:          "reversed range",
+			prefix:        "stable",
+			minMinor:      "4.18",
+			maxMinor:      "4.12",
+			expectedError: "4.18 is greater than 4.12",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			channels, err := rangeChannels(tc.prefix, tc.minMinor, tc.maxMinor)
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("rangeChannels returned an error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, channels); diff != "" {
+				t.Errorf("Channels mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
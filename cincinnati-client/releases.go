@@ -0,0 +1,166 @@
+package cincinnaticlient
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Sorted returns the releases ordered ascending by semantic version.
+// Releases whose version cannot be parsed are placed last, in lexical order.
+func (v VersionReleases) Sorted() []Release {
+	sorted := make([]Release, 0, len(v))
+	for _, ver := range sortedVersions(v) {
+		sorted = append(sorted, v[ver])
+	}
+	return sorted
+}
+
+// SortedChannels returns the channel names in lexical order.
+func (r ReleasesByChannel) SortedChannels() []string {
+	return slices.Sorted(maps.Keys(r))
+}
+
+// LatestPerMinor returns the highest release of every minor version, keyed by "major.minor".
+// Versions are compared using semantic-version ordering, ties (e.g. versions differing only
+// in build metadata) are broken by picking the lexically greater version string.
+// Releases whose version cannot be parsed are ignored.
+func (v VersionReleases) LatestPerMinor() map[string]Release {
+	latest := make(map[string]Release)
+	latestVersions := make(map[string]*semver.Version)
+	for ver, release := range v {
+		sv, err := semver.NewVersion(ver)
+		if err != nil {
+			continue
+		}
+		minor := fmt.Sprintf("%d.%d", sv.Major(), sv.Minor())
+		current, ok := latestVersions[minor]
+		if ok {
+			cmp := sv.Compare(current)
+			if cmp < 0 || (cmp == 0 && ver < latest[minor].Version) {
+				continue
+			}
+		}
+		latest[minor] = release
+		latestVersions[minor] = sv
+	}
+	return latest
+}
+
+// IsConditionalOnly checks if the release can only be upgraded through conditional edges
+// whose risks were not accepted, that is, it has ConditionalUpgrades but no AvailableUpgrades.
+func (r Release) IsConditionalOnly() bool {
+	return len(r.AvailableUpgrades) == 0 && len(r.ConditionalUpgrades) > 0
+}
+
+// HasUpgradeTo checks if the release can be upgraded to the given version,
+// that is, if the version is listed in its AvailableUpgrades.
+func (r Release) HasUpgradeTo(version string) bool {
+	return slices.Contains(r.AvailableUpgrades, version)
+}
+
+// HasConditionalUpgradeTo checks if a conditional edge leads from the release to the given version,
+// that is, if the version is listed in its ConditionalUpgrades, regardless of whether its risks were accepted.
+func (r Release) HasConditionalUpgradeTo(version string) bool {
+	_, ok := r.ConditionalUpgrades[version]
+	return ok
+}
+
+// PayloadDigest returns the "sha256:..." digest the Payload pullspec is pinned to,
+// e.g. "sha256:abc" for "quay.io/openshift-release-dev/ocp-release@sha256:abc".
+// It returns false when the payload is empty or references a tag instead of a digest.
+func (r Release) PayloadDigest() (string, bool) {
+	_, digest, ok := strings.Cut(r.Payload, "@")
+	if !ok || !strings.HasPrefix(digest, "sha256:") || len(digest) == len("sha256:") {
+		return "", false
+	}
+	return digest, true
+}
+
+// Equal checks if both releases have the same version, arch, payload and metadata,
+// and the same set of AvailableUpgrades regardless of their order.
+// The channel, the conditional upgrades and BelowMin are not compared.
+func (r Release) Equal(other Release) bool {
+	return r.Version == other.Version &&
+		r.Arch == other.Arch &&
+		r.Payload == other.Payload &&
+		maps.Equal(r.Metadata, other.Metadata) &&
+		slices.Equal(upgradeSet(r.AvailableUpgrades), upgradeSet(other.AvailableUpgrades))
+}
+
+// upgradeSet returns the upgrades sorted and without duplicates.
+func upgradeSet(upgrades []string) []string {
+	set := slices.Clone(upgrades)
+	slices.Sort(set)
+	return slices.Compact(set)
+}
+
+// Leaves returns the releases without AvailableUpgrades, that is, the upgrade dead-ends,
+// sorted in ascending semantic-version order.
+func (v VersionReleases) Leaves() []Release {
+	var leaves []Release
+	for _, release := range v.Sorted() {
+		if len(release.AvailableUpgrades) == 0 {
+			leaves = append(leaves, release)
+		}
+	}
+	return leaves
+}
+
+// DeadEnds returns the versions of the releases without AvailableUpgrades, see Leaves.
+func (v VersionReleases) DeadEnds() []string {
+	var deadEnds []string
+	for _, release := range v.Leaves() {
+		deadEnds = append(deadEnds, release.Version)
+	}
+	return deadEnds
+}
+
+// DeadEndRatio returns the fraction of the releases without AvailableUpgrades,
+// from 0 when every release can be upgraded to 1 when none can.
+// It returns 0 for an empty set.
+func (v VersionReleases) DeadEndRatio() float64 {
+	if len(v) == 0 {
+		return 0
+	}
+	return float64(len(v.Leaves())) / float64(len(v))
+}
+
+// Roots returns the releases that are not listed in the AvailableUpgrades of any release,
+// sorted in ascending semantic-version order.
+func (v VersionReleases) Roots() []Release {
+	targets := make(map[string]bool)
+	for _, release := range v {
+		for _, up := range release.AvailableUpgrades {
+			targets[up] = true
+		}
+	}
+	var roots []Release
+	for _, release := range v.Sorted() {
+		if !targets[release.Version] {
+			roots = append(roots, release)
+		}
+	}
+	return roots
+}
+
+// Validate checks that every version listed in the AvailableUpgrades of a release
+// is also a release of its channel, e.g. to catch inconsistencies in mirrored graphs.
+// It returns an error for every dangling upgrade, ordered by channel, release and upgrade.
+func (r ReleasesByChannel) Validate() []error {
+	var errs []error
+	for _, channel := range r.SortedChannels() {
+		releases := r[channel]
+		for _, ver := range sortedVersions(releases) {
+			for _, up := range releases[ver].AvailableUpgrades {
+				if _, ok := releases[up]; !ok {
+					errs = append(errs, fmt.Errorf("channel %s: release %s upgrades to %s which is not a release of the channel", channel, ver, up))
+				}
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,371 @@
+package cincinnaticlient
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSorted(t *testing.T) {
+	releases := VersionReleases{
+		"4.16.10": Release{Version: "4.16.10"},
+		"4.16.9":  Release{Version: "4.16.9"},
+		"invalid": Release{Version: "invalid"},
+		"4.17.0":  Release{Version: "4.17.0"},
+		"4.16.1":  Release{Version: "4.16.1"},
+	}
+
+	expected := []Release{
+		{Version: "4.16.1"},
+		{Version: "4.16.9"},
+		{Version: "4.16.10"},
+		{Version: "4.17.0"},
+		{Version: "invalid"},
+	}
+	if diff := cmp.Diff(expected, releases.Sorted()); diff != "" {
+		t.Errorf("Sorted releases mismatch (-expected +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]Release{}, VersionReleases{}.Sorted()); diff != "" {
+		t.Errorf("Sorted releases mismatch for an empty set (-expected +got):\n%s", diff)
+	}
+}
+
+func TestSortedChannels(t *testing.T) {
+	releases := ReleasesByChannel{
+		"stable-4.17": nil,
+		"fast-4.16":   nil,
+		"stable-4.16": nil,
+	}
+
+	expected := []string{"fast-4.16", "stable-4.16", "stable-4.17"}
+	if diff := cmp.Diff(expected, releases.SortedChannels()); diff != "" {
+		t.Errorf("Sorted channels mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestLatestPerMinor(t *testing.T) {
+	releases := VersionReleases{
+		"4.16.1":      Release{Version: "4.16.1", Payload: "p-4.16.1"},
+		"4.16.10":     Release{Version: "4.16.10", Payload: "p-4.16.10"},
+		"4.16.9":      Release{Version: "4.16.9", Payload: "p-4.16.9"},
+		"4.17.0":      Release{Version: "4.17.0", Payload: "p-4.17.0"},
+		"4.17.2":      Release{Version: "4.17.2", Payload: "p-4.17.2"},
+		"4.17.2+a":    Release{Version: "4.17.2+a", Payload: "p-4.17.2+a"},
+		"4.17.3-rc.1": Release{Version: "4.17.3-rc.1", Payload: "p-4.17.3-rc.1"},
+		"invalid":     Release{Version: "invalid"},
+	}
+
+	expected := map[string]Release{
+		"4.16": {Version: "4.16.10", Payload: "p-4.16.10"},
+		"4.17": {Version: "4.17.3-rc.1", Payload: "p-4.17.3-rc.1"},
+	}
+	// run a few times since map iteration order is random
+	for i := 0; i < 10; i++ {
+		if diff := cmp.Diff(expected, releases.LatestPerMinor()); diff != "" {
+			t.Fatalf("Latest releases mismatch (-expected +got):\n%s", diff)
+		}
+	}
+
+	delete(releases, "4.17.3-rc.1")
+	expected["4.17"] = Release{Version: "4.17.2+a", Payload: "p-4.17.2+a"}
+	for i := 0; i < 10; i++ {
+		if diff := cmp.Diff(expected, releases.LatestPerMinor()); diff != "" {
+			t.Fatalf("Latest releases mismatch for a tie (-expected +got):\n%s", diff)
+		}
+	}
+}
+
+func TestIsConditionalOnly(t *testing.T) {
+	tests := []struct {
+		name     string
+		release  Release
+		expected bool
+	}{
+		{
+			name: "only gated upgrades",
+			release: Release{
+				Version:             "4.16.1",
+				ConditionalUpgrades: map[string][]Risk{"4.16.3": {{Name: "RiskA"}}},
+			},
+			expected: true,
+		},
+		{
+			name: "only unconditional upgrades",
+			release: Release{
+				Version:           "4.16.1",
+				AvailableUpgrades: []string{"4.16.2"},
+			},
+		},
+		{
+			name: "accepted conditional upgrades",
+			release: Release{
+				Version:             "4.16.1",
+				AvailableUpgrades:   []string{"4.16.3"},
+				ConditionalUpgrades: map[string][]Risk{"4.16.3": {{Name: "RiskA"}}},
+			},
+		},
+		{
+			name:    "leaf",
+			release: Release{Version: "4.16.5"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.release.IsConditionalOnly(); got != tc.expected {
+				t.Errorf("Expected IsConditionalOnly to be %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestReleaseEqual(t *testing.T) {
+	release := Release{
+		Version:           "4.16.1",
+		Arch:              "amd64",
+		Payload:           "p-4.16.1",
+		AvailableUpgrades: []string{"4.16.2", "4.16.3"},
+		Metadata:          map[string]string{releaseChannelsMetadataKey: "stable-4.16"},
+	}
+	tests := []struct {
+		name     string
+		modify   func(r *Release)
+		expected bool
+	}{
+		{
+			name:     "identical",
+			modify:   func(r *Release) {},
+			expected: true,
+		},
+		{
+			name:     "upgrades in a different order",
+			modify:   func(r *Release) { r.AvailableUpgrades = []string{"4.16.3", "4.16.2"} },
+			expected: true,
+		},
+		{
+			name:     "different channel",
+			modify:   func(r *Release) { r.Channel = "fast-4.16" },
+			expected: true,
+		},
+		{
+			name:   "different upgrade target",
+			modify: func(r *Release) { r.AvailableUpgrades = []string{"4.16.2", "4.16.4"} },
+		},
+		{
+			name:   "missing upgrade target",
+			modify: func(r *Release) { r.AvailableUpgrades = []string{"4.16.2"} },
+		},
+		{
+			name:   "different payload",
+			modify: func(r *Release) { r.Payload = "p-other" },
+		},
+		{
+			name:   "different arch",
+			modify: func(r *Release) { r.Arch = "arm64" },
+		},
+		{
+			name:   "different metadata",
+			modify: func(r *Release) { r.Metadata = map[string]string{releaseChannelsMetadataKey: "fast-4.16"} },
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			other := release
+			other.AvailableUpgrades = slices.Clone(release.AvailableUpgrades)
+			tc.modify(&other)
+			if got := release.Equal(other); got != tc.expected {
+				t.Errorf("Expected Equal to be %v, got %v", tc.expected, got)
+			}
+			if got := other.Equal(release); got != tc.expected {
+				t.Errorf("Expected the reversed Equal to be %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestHasUpgradeTo(t *testing.T) {
+	release := Release{
+		Version:           "4.16.1",
+		AvailableUpgrades: []string{"4.16.2", "4.16.3"},
+		ConditionalUpgrades: map[string][]Risk{
+			"4.16.3": {{Name: "RiskA"}},
+			"4.16.4": {{Name: "RiskB"}},
+		},
+	}
+	tests := []struct {
+		name                string
+		target              string
+		expectedUpgrade     bool
+		expectedConditional bool
+	}{
+		{
+			name:            "unconditional target",
+			target:          "4.16.2",
+			expectedUpgrade: true,
+		},
+		{
+			name:                "accepted conditional target",
+			target:              "4.16.3",
+			expectedUpgrade:     true,
+			expectedConditional: true,
+		},
+		{
+			name:                "conditional-only target",
+			target:              "4.16.4",
+			expectedConditional: true,
+		},
+		{
+			name:   "absent target",
+			target: "4.16.5",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := release.HasUpgradeTo(tc.target); got != tc.expectedUpgrade {
+				t.Errorf("Expected HasUpgradeTo to be %v, got %v", tc.expectedUpgrade, got)
+			}
+			if got := release.HasConditionalUpgradeTo(tc.target); got != tc.expectedConditional {
+				t.Errorf("Expected HasConditionalUpgradeTo to be %v, got %v", tc.expectedConditional, got)
+			}
+		})
+	}
+}
+
+func TestPayloadDigest(t *testing.T) {
+	tests := []struct {
+		name           string
+		payload        string
+		expectedDigest string
+		expectedOK     bool
+	}{
+		{
+			name:           "digest pullspec",
+			payload:        "quay.io/openshift-release-dev/ocp-release@sha256:fd9e1ab33fff8ac2d4e8fcd6d0a5cc6a7ec1c0e2b2ab3f94d1ee7cc1b3c9c1e1",
+			expectedDigest: "sha256:fd9e1ab33fff8ac2d4e8fcd6d0a5cc6a7ec1c0e2b2ab3f94d1ee7cc1b3c9c1e1",
+			expectedOK:     true,
+		},
+		{
+			name:    "tagged pullspec",
+			payload: "quay.io/openshift-release-dev/ocp-release:4.16.1-x86_64",
+		},
+		{
+			name: "empty payload",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			digest, ok := Release{Payload: tc.payload}.PayloadDigest()
+			if ok != tc.expectedOK {
+				t.Errorf("Expected PayloadDigest to return %v, got %v", tc.expectedOK, ok)
+			}
+			if digest != tc.expectedDigest {
+				t.Errorf("Expected digest %q, got %q", tc.expectedDigest, digest)
+			}
+		})
+	}
+}
+
+func TestLeavesAndRoots(t *testing.T) {
+	releases := VersionReleases{
+		"4.16.1":  Release{Version: "4.16.1", AvailableUpgrades: []string{"4.16.3", "4.16.2"}},
+		"4.16.2":  Release{Version: "4.16.2", AvailableUpgrades: []string{"4.16.4"}},
+		"4.16.3":  Release{Version: "4.16.3"},
+		"4.16.4":  Release{Version: "4.16.4"},
+		"4.16.10": Release{Version: "4.16.10", AvailableUpgrades: []string{"4.16.4"}},
+	}
+
+	expectedLeaves := []Release{
+		{Version: "4.16.3"},
+		{Version: "4.16.4"},
+	}
+	if diff := cmp.Diff(expectedLeaves, releases.Leaves()); diff != "" {
+		t.Errorf("Leaves mismatch (-expected +got):\n%s", diff)
+	}
+	expectedRoots := []Release{
+		{Version: "4.16.1", AvailableUpgrades: []string{"4.16.3", "4.16.2"}},
+		{Version: "4.16.10", AvailableUpgrades: []string{"4.16.4"}},
+	}
+	if diff := cmp.Diff(expectedRoots, releases.Roots()); diff != "" {
+		t.Errorf("Roots mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	releases := ReleasesByChannel{
+		"stable-4.16": {
+			"4.16.1": Release{Version: "4.16.1", AvailableUpgrades: []string{"4.16.2", "4.16.9"}},
+			"4.16.2": Release{Version: "4.16.2"},
+		},
+		"stable-4.17": {
+			"4.16.2": Release{Version: "4.16.2", AvailableUpgrades: []string{"4.17.0"}},
+			"4.17.0": Release{Version: "4.17.0", AvailableUpgrades: []string{"4.17.1"}},
+		},
+	}
+
+	var got []string
+	for _, err := range releases.Validate() {
+		got = append(got, err.Error())
+	}
+	expected := []string{
+		"channel stable-4.16: release 4.16.1 upgrades to 4.16.9 which is not a release of the channel",
+		"channel stable-4.17: release 4.17.0 upgrades to 4.17.1 which is not a release of the channel",
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("Validation errors mismatch (-expected +got):\n%s", diff)
+	}
+
+	delete(releases, "stable-4.17")
+	releases["stable-4.16"]["4.16.9"] = Release{Version: "4.16.9"}
+	if errs := releases.Validate(); len(errs) != 0 {
+		t.Errorf("Expected no validation errors, got %v", errs)
+	}
+}
+
+func TestDeadEnds(t *testing.T) {
+	tests := []struct {
+		name             string
+		releases         VersionReleases
+		expectedDeadEnds []string
+		expectedRatio    float64
+	}{
+		{
+			name: "all leaves",
+			releases: VersionReleases{
+				"4.16.1": Release{Version: "4.16.1"},
+				"4.16.2": Release{Version: "4.16.2"},
+			},
+			expectedDeadEnds: []string{"4.16.1", "4.16.2"},
+			expectedRatio:    1,
+		},
+		{
+			name: "chain",
+			releases: VersionReleases{
+				"4.16.1":  Release{Version: "4.16.1", AvailableUpgrades: []string{"4.16.2"}},
+				"4.16.2":  Release{Version: "4.16.2", AvailableUpgrades: []string{"4.16.3"}},
+				"4.16.3":  Release{Version: "4.16.3", AvailableUpgrades: []string{"4.16.10"}},
+				"4.16.10": Release{Version: "4.16.10"},
+			},
+			expectedDeadEnds: []string{"4.16.10"},
+			expectedRatio:    0.25,
+		},
+		{
+			name:     "empty",
+			releases: VersionReleases{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expectedDeadEnds, tc.releases.DeadEnds()); diff != "" {
+				t.Errorf("Dead ends mismatch (-expected +got):\n%s", diff)
+			}
+			if got := tc.releases.DeadEndRatio(); got != tc.expectedRatio {
+				t.Errorf("Expected a dead-end ratio of %v, got %v", tc.expectedRatio, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package cincinnaticlient
+
+import "slices"
+
+// RiskEvaluator decides whether a conditional edge risk is acceptable for the current cluster,
+// for example because the cluster is not exposed to it or because the caller accepted it.
+// A conditional edge is applied only if every one of its risks is accepted.
+type RiskEvaluator interface {
+	AcceptRisk(risk Risk) bool
+}
+
+// RiskEvaluatorFunc is an adapter to allow the use of ordinary functions as a RiskEvaluator.
+type RiskEvaluatorFunc func(risk Risk) bool
+
+// AcceptRisk calls f(risk).
+func (f RiskEvaluatorFunc) AcceptRisk(risk Risk) bool {
+	return f(risk)
+}
+
+// AllowListRiskEvaluator accepts the risks whose names are on the list, ignoring their matching rules.
+// A list containing AllConditionalEdgeRisks accepts every risk.
+// It is the default evaluator used by DiscoverReleases.
+type AllowListRiskEvaluator []string
+
+// AcceptRisk checks if the risk is on the allow-list.
+func (l AllowListRiskEvaluator) AcceptRisk(risk Risk) bool {
+	return slices.Contains(l, AllConditionalEdgeRisks) || slices.Contains(l, risk.Name)
+}
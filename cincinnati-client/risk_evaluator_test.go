@@ -0,0 +1,89 @@
+package cincinnaticlient
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeClusterRiskEvaluator accepts risks the fake cluster isn't exposed to,
+// based on the cluster's infrastructure provider.
+type fakeClusterRiskEvaluator struct {
+	provider string
+}
+
+func (e fakeClusterRiskEvaluator) AcceptRisk(risk Risk) bool {
+	for _, rule := range risk.MatchingRules {
+		switch rule.Type {
+		case "Always":
+			return false
+		case "PromQL":
+			if rule.PromQL != nil && strings.Contains(rule.PromQL.PromQL, `type="`+e.provider+`"`) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestDiscoverReleasesWithRiskEvaluator(t *testing.T) {
+	awsOnly := Risk{
+		Name:    "AWSOnly",
+		Message: "Clusters on AWS may fail to upgrade.",
+		URL:     "https://issues.example.com/AWS-1",
+		MatchingRules: []MatchingRule{
+			{Type: "PromQL", PromQL: &PromQLMatchingRule{PromQL: `cluster_infrastructure_provider{type="AWS"}`}},
+		},
+	}
+	everyone := Risk{
+		Name:          "Everyone",
+		Message:       "All clusters are affected.",
+		URL:           "https://issues.example.com/ALL-1",
+		MatchingRules: []MatchingRule{{Type: "Always"}},
+	}
+
+	tests := []struct {
+		name                        string
+		opts                        []Option
+		allowedConditionalEdgeRisks []string
+		expectedUpgrades            []string
+	}{
+		{
+			name:                        "default evaluator uses the allow-list",
+			allowedConditionalEdgeRisks: []string{"Everyone"},
+			expectedUpgrades:            []string{"4.16.4"},
+		},
+		{
+			name:             "custom evaluator, cluster not exposed to the AWS risk",
+			opts:             []Option{WithRiskEvaluator(fakeClusterRiskEvaluator{provider: "GCP"})},
+			expectedUpgrades: []string{"4.16.3"},
+		},
+		{
+			name:                        "custom evaluator, cluster exposed to the AWS risk, allow-list ignored",
+			opts:                        []Option{WithRiskEvaluator(fakeClusterRiskEvaluator{provider: "AWS"})},
+			allowedConditionalEdgeRisks: []string{"AWSOnly"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hClient := fakeHTTPClientForFiles(t, map[string]string{
+				testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-conditional-edges-matching-rules.json",
+			})
+			releases, err := New(hClient, tc.opts...).DiscoverReleases(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64", tc.allowedConditionalEdgeRisks)
+			if err != nil {
+				t.Fatalf("Failed to discover releases: %v", err)
+			}
+
+			release := releases["stable-4.16"]["4.16.1"]
+			if diff := cmp.Diff(tc.expectedUpgrades, release.AvailableUpgrades); diff != "" {
+				t.Errorf("AvailableUpgrades mismatch (-expected +got):\n%s", diff)
+			}
+			expectedConditionalUpgrades := map[string][]Risk{"4.16.3": {awsOnly}, "4.16.4": {everyone}}
+			if diff := cmp.Diff(expectedConditionalUpgrades, release.ConditionalUpgrades); diff != "" {
+				t.Errorf("ConditionalUpgrades mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package cincinnaticlient
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// DiscoverToTarget is like DiscoverReleases but only discovers what is needed to plan
+// an upgrade to the targetVersion, e.g. 4.18.3: channels above the minor of the target
+// are never fetched and releases above the target are dropped along with their edges.
+// The targetVersion replaces the ceiling set with WithMaxVersion. It is parsed and filtered
+// like the versions of the releases, so it must not be lower than the version of the startChannel
+// and must pass the other filters of the client, e.g. WithVersionConstraint.
+func (c *Client) DiscoverToTarget(graphURL *url.URL, startChannel, arch, targetVersion string, allowedConditionalEdgeRisks []string) (ReleasesByChannel, error) {
+	target, err := c.versionParser(targetVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target version %q: %w", targetVersion, err)
+	}
+	// a full version ceiling includes the channels up to its minor and the releases up to itself
+	start, err := c.prepareDiscovery(graphURL, startChannel, arch, targetVersion)
+	if err != nil {
+		return nil, err
+	}
+	switch reason := start.filter.exclusionReason(target); reason {
+	case "":
+	case belowMinReason:
+		return nil, fmt.Errorf("invalid target version %q: lower than the minimum version %s", targetVersion, start.filter.minVersion.Original())
+	default:
+		return nil, fmt.Errorf("invalid target version %q: %s", targetVersion, reason)
+	}
+	d, err := c.discoverFrom(start, startChannel, allowedConditionalEdgeRisks)
+	if err != nil {
+		return nil, err
+	}
+	return d.releases, nil
+}
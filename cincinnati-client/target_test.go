@@ -0,0 +1,159 @@
+package cincinnaticlient
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/go-version"
+)
+
+func TestDiscoverToTarget(t *testing.T) {
+	files := map[string]string{
+		testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-max-version.json",
+		testGraphURL + "?arch=amd64&channel=stable-4.17": "testdata/discover-releases-stable-4.17-target.json",
+	}
+	var requested []string
+	hClient := &http.Client{
+		Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+			requested = append(requested, req.URL.String())
+			filename, ok := files[req.URL.String()]
+			if !ok {
+				return &http.Response{
+					StatusCode: http.StatusNotFound,
+					Body:       io.NopCloser(strings.NewReader("")),
+				}
+			}
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatalf("Failed to read file %s: %v", filename, err)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(data)),
+			}
+		}),
+	}
+	target := New(hClient)
+
+	releases, err := target.DiscoverToTarget(nil, "stable-4.16", "amd64", "4.17.5", nil)
+	if err != nil {
+		t.Fatalf("Failed to discover releases: %v", err)
+	}
+	// stable-4.18 is listed in the metadata but above the minor of the target
+	expectedRequests := []string{
+		testGraphURL + "?arch=amd64&channel=stable-4.16",
+		testGraphURL + "?arch=amd64&channel=stable-4.17",
+	}
+	if diff := cmp.Diff(expectedRequests, requested); diff != "" {
+		t.Errorf("Requested URLs mismatch (-expected +got):\n%s", diff)
+	}
+	expected := map[string][]string{
+		"stable-4.16": {"4.16.2"},
+		"stable-4.17": {"4.16.2", "4.17.5"},
+	}
+	got := map[string][]string{}
+	for channel, versionReleases := range releases {
+		got[channel] = sortedVersions(versionReleases)
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("Versions mismatch (-expected +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"4.17.5"}, releases["stable-4.17"]["4.16.2"].AvailableUpgrades); diff != "" {
+		t.Errorf("Upgrades mismatch (-expected +got):\n%s", diff)
+	}
+	if len(releases["stable-4.17"]["4.17.5"].AvailableUpgrades) != 0 {
+		t.Errorf("Expected the upgrades above the target to be dropped, got %v", releases["stable-4.17"]["4.17.5"].AvailableUpgrades)
+	}
+
+	_, err = target.DiscoverToTarget(nil, "stable-4.16", "amd64", "latest", nil)
+	if err == nil || !strings.Contains(err.Error(), `invalid target version "latest"`) {
+		t.Errorf("Expected an invalid target version error, got %v", err)
+	}
+}
+
+func TestDiscoverToTargetBelowStartChannel(t *testing.T) {
+	// no request must be sent for a target below the start channel
+	hClient := fakeHTTPClientForFiles(t, map[string]string{})
+
+	_, err := New(hClient).DiscoverToTarget(nil, "stable-4.16", "amd64", "4.15.9", nil)
+	expectedError := `invalid target version "4.15.9": lower than the minimum version 4.16`
+	if err == nil {
+		t.Fatalf("Expected error containing %q, but got none", expectedError)
+	}
+	if !strings.Contains(err.Error(), expectedError) {
+		t.Errorf("Expected error containing %q, got %q", expectedError, err.Error())
+	}
+}
+
+func TestDiscoverToTargetUsesTheClientFilters(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          []Option
+		targetVersion string
+		expectedError string
+	}{
+		{
+			name:          "target not satisfying the version constraint",
+			opts:          []Option{WithVersionConstraint("< 4.17")},
+			targetVersion: "4.17.5",
+			expectedError: `invalid target version "4.17.5": does not satisfy version constraint`,
+		},
+		{
+			name:          "excluded target",
+			opts:          []Option{WithExcludeVersions([]string{"4.17.5"})},
+			targetVersion: "4.17.5",
+			expectedError: `invalid target version "4.17.5": excluded version`,
+		},
+		{
+			name:          "target below the min version",
+			opts:          []Option{WithMinVersion("4.16.5")},
+			targetVersion: "4.16.3",
+			expectedError: `invalid target version "4.16.3": lower than the minimum version 4.16.5`,
+		},
+		{
+			name:          "target rejected by the version parser",
+			opts:          []Option{WithVersionParser(func(string) (*version.Version, error) { return nil, errors.New("unsupported version") })},
+			targetVersion: "4.17.5",
+			expectedError: `invalid target version "4.17.5": unsupported version`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// no request must be sent for an invalid target
+			hClient := fakeHTTPClientForFiles(t, map[string]string{})
+
+			_, err := New(hClient, tc.opts...).DiscoverToTarget(nil, "stable-4.16", "amd64", tc.targetVersion, nil)
+			if err == nil {
+				t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+			}
+			if !strings.Contains(err.Error(), tc.expectedError) {
+				t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+			}
+		})
+	}
+}
+
+func TestDiscoverToTargetWithVersionParser(t *testing.T) {
+	hClient := fakeHTTPClientForFiles(t, map[string]string{
+		testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-max-version.json",
+		testGraphURL + "?arch=amd64&channel=stable-4.17": "testdata/discover-releases-stable-4.17-target.json",
+	})
+	parse := func(v string) (*version.Version, error) {
+		return version.NewVersion(strings.TrimPrefix(v, "ocp-"))
+	}
+
+	releases, err := New(hClient, WithVersionParser(parse)).DiscoverToTarget(nil, "stable-4.16", "amd64", "ocp-4.17.5", nil)
+	if err != nil {
+		t.Fatalf("Failed to discover releases: %v", err)
+	}
+	if _, ok := releases["stable-4.17"]["4.17.5"]; !ok {
+		t.Errorf("Expected the target 4.17.5 to be discovered, got %v", sortedVersions(releases["stable-4.17"]))
+	}
+}
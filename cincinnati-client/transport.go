@@ -0,0 +1,57 @@
+package cincinnaticlient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// configureTransport gives the client a transport honoring the transport options, e.g. WithProxyURL,
+// if any is set and the http.Client wasn't given a transport. The http.Client is copied,
+// so that the one passed to New, e.g. http.DefaultClient, isn't modified.
+// A transport set on the http.Client wins over the transport options.
+// An error is recorded in transportErr.
+func (c *Client) configureTransport() {
+	if (c.proxyURL == nil && c.tlsConfig == nil && c.rootCAFile == "") || c.httpClient.Transport != nil {
+		return
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if c.proxyURL != nil {
+		transport.Proxy = http.ProxyURL(c.proxyURL)
+	}
+	tlsConfig, err := c.transportTLSConfig()
+	if err != nil {
+		c.transportErr = err
+		return
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	httpClient := *c.httpClient
+	httpClient.Transport = transport
+	c.httpClient = &httpClient
+}
+
+// transportTLSConfig returns the TLS configuration set with WithTLSConfig, if any,
+// trusting the certificates of the rootCAFile instead of its RootCAs if set.
+func (c *Client) transportTLSConfig() (*tls.Config, error) {
+	if c.rootCAFile == "" {
+		return c.tlsConfig, nil
+	}
+	pem, err := os.ReadFile(c.rootCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading root CA file %s: %w", c.rootCAFile, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("invalid root CA file %s: no PEM certificates found", c.rootCAFile)
+	}
+	tlsConfig := &tls.Config{}
+	if c.tlsConfig != nil {
+		tlsConfig = c.tlsConfig.Clone()
+	}
+	tlsConfig.RootCAs = pool
+	return tlsConfig, nil
+}
@@ -0,0 +1,140 @@
+package cincinnaticlient
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFetchGraphWithProxyURL(t *testing.T) {
+	data, err := os.ReadFile("testdata/fetch-graph-valid-response.json")
+	if err != nil {
+		t.Fatalf("Failed to read test data file: %v", err)
+	}
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.Write(data)
+	}))
+	defer proxy.Close()
+	graphURL := "http://graph.example.com/api/upgrades_info/graph"
+
+	t.Run("requests are sent through the proxy", func(t *testing.T) {
+		proxied = nil
+		target := New(nil, WithProxyURL(rawURLtoURLOrDie(proxy.URL)))
+		if _, err := target.fetchGraph(rawURLtoURLOrDie(graphURL), "stable-4.16", "amd64"); err != nil {
+			t.Fatalf("fetchGraph returned an error: %v", err)
+		}
+		if len(proxied) != 1 || proxied[0] != graphURL+"?arch=amd64&channel=stable-4.16" {
+			t.Errorf("Expected a single proxied request to the graph URL, got %v", proxied)
+		}
+		if http.DefaultClient.Transport != nil {
+			t.Errorf("Expected http.DefaultClient to be left unmodified")
+		}
+	})
+
+	t.Run("the caller's transport wins", func(t *testing.T) {
+		proxied = nil
+		requests := 0
+		hClient := &http.Client{
+			Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+				requests++
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewReader(data)),
+				}
+			}),
+		}
+		target := New(hClient, WithProxyURL(rawURLtoURLOrDie(proxy.URL)))
+		if _, err := target.fetchGraph(rawURLtoURLOrDie(graphURL), "stable-4.16", "amd64"); err != nil {
+			t.Fatalf("fetchGraph returned an error: %v", err)
+		}
+		if requests != 1 || len(proxied) != 0 {
+			t.Errorf("Expected the request to go through the caller's transport only, got %d requests and %d proxied", requests, len(proxied))
+		}
+	})
+}
+
+func TestDiscoverReleasesWithRootCA(t *testing.T) {
+	data, err := os.ReadFile("testdata/discover-releases-stable-4.16.json")
+	if err != nil {
+		t.Fatalf("Failed to read test data file: %v", err)
+	}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.crt")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+		t.Fatalf("Failed to write the CA file: %v", err)
+	}
+	emptyFile := filepath.Join(dir, "empty.crt")
+	if err := os.WriteFile(emptyFile, nil, 0o600); err != nil {
+		t.Fatalf("Failed to write the empty CA file: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	tests := []struct {
+		name          string
+		opts          []Option
+		expectedError string
+	}{
+		{
+			name:          "untrusted CA",
+			expectedError: "certificate",
+		},
+		{
+			name: "CA trusted with a root CA file",
+			opts: []Option{WithRootCAFile(caFile)},
+		},
+		{
+			name: "CA trusted with a TLS config",
+			opts: []Option{WithTLSConfig(&tls.Config{RootCAs: pool})},
+		},
+		{
+			name:          "root CA file without certificates",
+			opts:          []Option{WithRootCAFile(emptyFile)},
+			expectedError: "no PEM certificates found",
+		},
+		{
+			name:          "missing root CA file",
+			opts:          []Option{WithRootCAFile(filepath.Join(dir, "missing.crt"))},
+			expectedError: "error reading root CA file",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// a client without a transport, so that the options apply
+			target := New(&http.Client{}, tc.opts...)
+			releases, err := target.DiscoverReleases(rawURLtoURLOrDie(server.URL), "stable-4.16", "amd64", nil)
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to discover releases: %v", err)
+			}
+			if len(releases["stable-4.16"]) != 1 {
+				t.Errorf("Expected a single release, got %+v", releases)
+			}
+		})
+	}
+}
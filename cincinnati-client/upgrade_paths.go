@@ -0,0 +1,260 @@
+package cincinnaticlient
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/hashicorp/go-version"
+)
+
+// DefaultMaxUpgradePathDepth is the maximum number of hops
+// ListAllUpgradePaths follows before abandoning a path.
+const DefaultMaxUpgradePathDepth = 10
+
+// ListAllUpgradePaths enumerates every simple path (no repeated version)
+// from the from version to the to version using the AvailableUpgrades adjacency.
+// Paths longer than DefaultMaxUpgradePathDepth hops are not reported.
+func ListAllUpgradePaths(releases VersionReleases, from, to string) ([][]string, error) {
+	return ListAllUpgradePathsWithMaxDepth(releases, from, to, DefaultMaxUpgradePathDepth)
+}
+
+// ListAllUpgradePathsWithMaxDepth is like ListAllUpgradePaths but caps
+// the number of hops of a single path at maxDepth.
+// The returned paths are sorted by comparing their versions in order.
+func ListAllUpgradePathsWithMaxDepth(releases VersionReleases, from, to string, maxDepth int) ([][]string, error) {
+	if maxDepth < 0 {
+		return nil, fmt.Errorf("invalid max depth: %d", maxDepth)
+	}
+	if _, ok := releases[from]; !ok {
+		return nil, fmt.Errorf("unknown source version: %s", from)
+	}
+	if _, ok := releases[to]; !ok {
+		return nil, fmt.Errorf("unknown target version: %s", to)
+	}
+
+	var paths [][]string
+	visited := map[string]bool{from: true}
+	path := []string{from}
+
+	var walk func(current string)
+	walk = func(current string) {
+		if current == to {
+			paths = append(paths, slices.Clone(path))
+			return
+		}
+		if len(path)-1 >= maxDepth {
+			return
+		}
+		for _, next := range releases[current].AvailableUpgrades {
+			if visited[next] {
+				continue
+			}
+			if _, ok := releases[next]; !ok {
+				continue
+			}
+			visited[next] = true
+			path = append(path, next)
+			walk(next)
+			path = path[:len(path)-1]
+			visited[next] = false
+		}
+	}
+	walk(from)
+
+	if err := sortUpgradePaths(paths); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// AvailableDowngradesFrom returns the versions that list target in their AvailableUpgrades,
+// that is, the versions that can upgrade into target. Conditional edges are reflected
+// as long as their risks were accepted during discovery.
+// The result is sorted in ascending semantic-version order, versions that cannot be parsed come last.
+func AvailableDowngradesFrom(releases VersionReleases, target string) []string {
+	var sources []string
+	for ver, r := range releases {
+		if r.HasUpgradeTo(target) {
+			sources = append(sources, ver)
+		}
+	}
+	SortVersions(sources)
+	return sources
+}
+
+// DetectCycles returns the cycles found over the AvailableUpgrades adjacency,
+// which a well-formed graph doesn't have. Each cycle lists its versions in upgrade order
+// starting with the version the DFS entered it through, e.g. [4.16.1 4.16.2] for
+// 4.16.1 → 4.16.2 → 4.16.1. Versions and upgrades are visited in ascending semantic-version
+// order so that the result is deterministic. The result is empty for a DAG.
+func (v VersionReleases) DetectCycles() [][]string {
+	cycles := [][]string{}
+	// onStack holds the index of the versions on the recursion stack
+	onStack := map[string]int{}
+	done := map[string]bool{}
+	var stack []string
+
+	var visit func(current string)
+	visit = func(current string) {
+		onStack[current] = len(stack)
+		stack = append(stack, current)
+		upgrades := slices.Clone(v[current].AvailableUpgrades)
+		SortVersions(upgrades)
+		for _, next := range upgrades {
+			if _, ok := v[next]; !ok || done[next] {
+				continue
+			}
+			if idx, ok := onStack[next]; ok {
+				cycles = append(cycles, slices.Clone(stack[idx:]))
+				continue
+			}
+			visit(next)
+		}
+		stack = stack[:len(stack)-1]
+		delete(onStack, current)
+		done[current] = true
+	}
+	for _, ver := range sortedVersions(v) {
+		if !done[ver] {
+			visit(ver)
+		}
+	}
+	return cycles
+}
+
+// PlanEUSPath returns a shortest upgrade path from the from version to the to version
+// that honors the EUS rules: every intermediate stop is on an even minor, e.g. 4.14 or 4.16,
+// and no hop skips an even minor, e.g. 4.14.z → 4.18.z skips 4.16. Hops across major versions
+// are not allowed. Every hop is an edge of the AvailableUpgrades adjacency, which is followed
+// in ascending semantic-version order so that the result is deterministic.
+// An *EUSPathError is returned when no such path exists.
+func PlanEUSPath(releases VersionReleases, from, to string) ([]string, error) {
+	if _, ok := releases[from]; !ok {
+		return nil, fmt.Errorf("unknown source version: %s", from)
+	}
+	if _, ok := releases[to]; !ok {
+		return nil, fmt.Errorf("unknown target version: %s", to)
+	}
+
+	previous := map[string]string{}
+	visited := map[string]bool{from: true}
+	queue := []string{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == to {
+			path := []string{to}
+			for path[0] != from {
+				path = append([]string{previous[path[0]]}, path...)
+			}
+			return path, nil
+		}
+		if current != from && !onEvenMinor(current) {
+			continue
+		}
+		upgrades := slices.Clone(releases[current].AvailableUpgrades)
+		SortVersions(upgrades)
+		for _, next := range upgrades {
+			if _, ok := releases[next]; !ok || visited[next] || !eusHopAllowed(current, next) {
+				continue
+			}
+			visited[next] = true
+			previous[next] = current
+			queue = append(queue, next)
+		}
+	}
+	return nil, &EUSPathError{From: from, To: to}
+}
+
+// MaxReachable returns the highest version reachable from the from version by following
+// the AvailableUpgrades adjacency, or from itself if it can't be upgraded to a higher version.
+// Versions that cannot be parsed are not considered.
+func (v VersionReleases) MaxReachable(from string) (string, error) {
+	if _, ok := v[from]; !ok {
+		return "", fmt.Errorf("unknown source version: %s", from)
+	}
+	highest := from
+	highestVersion, err := version.NewVersion(from)
+	if err != nil {
+		return "", fmt.Errorf("invalid semantic version %q: %w", from, err)
+	}
+	visited := map[string]bool{from: true}
+	queue := []string{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range v[current].AvailableUpgrades {
+			if _, ok := v[next]; !ok || visited[next] {
+				continue
+			}
+			visited[next] = true
+			queue = append(queue, next)
+			if nextVersion, err := version.NewVersion(next); err == nil && nextVersion.GreaterThan(highestVersion) {
+				highest, highestVersion = next, nextVersion
+			}
+		}
+	}
+	return highest, nil
+}
+
+// onEvenMinor checks if the version is valid and has an even minor.
+func onEvenMinor(v string) bool {
+	ver, err := version.NewVersion(v)
+	if err != nil {
+		return false
+	}
+	return ver.Segments()[1]%2 == 0
+}
+
+// eusHopAllowed checks if both versions are valid and share the same major,
+// and there is no even minor strictly between their minors.
+func eusHopAllowed(from, to string) bool {
+	fromVersion, err := version.NewVersion(from)
+	if err != nil {
+		return false
+	}
+	toVersion, err := version.NewVersion(to)
+	if err != nil {
+		return false
+	}
+	fromSegments, toSegments := fromVersion.Segments(), toVersion.Segments()
+	if fromSegments[0] != toSegments[0] {
+		return false
+	}
+	for minor := fromSegments[1] + 1; minor < toSegments[1]; minor++ {
+		if minor%2 == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// sortUpgradePaths orders the paths by comparing their versions element by element.
+// A path that is a prefix of another sorts first.
+func sortUpgradePaths(paths [][]string) error {
+	parsed := make(map[string]*version.Version)
+	for _, path := range paths {
+		for _, v := range path {
+			if _, ok := parsed[v]; ok {
+				continue
+			}
+			ver, err := version.NewVersion(v)
+			if err != nil {
+				return fmt.Errorf("invalid semantic version %q in upgrade path: %w", v, err)
+			}
+			parsed[v] = ver
+		}
+	}
+
+	sort.Slice(paths, func(i, j int) bool {
+		a, b := paths[i], paths[j]
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if cmp := parsed[a[k]].Compare(parsed[b[k]]); cmp != 0 {
+				return cmp < 0
+			}
+		}
+		return len(a) < len(b)
+	})
+	return nil
+}
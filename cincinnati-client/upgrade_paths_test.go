@@ -0,0 +1,326 @@
+package cincinnaticlient
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestListAllUpgradePaths(t *testing.T) {
+	diamond := VersionReleases{
+		"4.16.1": Release{Version: "4.16.1", AvailableUpgrades: []string{"4.16.3", "4.16.2"}},
+		"4.16.2": Release{Version: "4.16.2", AvailableUpgrades: []string{"4.16.4"}},
+		"4.16.3": Release{Version: "4.16.3", AvailableUpgrades: []string{"4.16.4"}},
+		"4.16.4": Release{Version: "4.16.4"},
+	}
+
+	tests := []struct {
+		name          string
+		releases      VersionReleases
+		from          string
+		to            string
+		maxDepth      int
+		expected      [][]string
+		expectedError string
+	}{
+		{
+			name:     "diamond yields two paths",
+			releases: diamond,
+			from:     "4.16.1",
+			to:       "4.16.4",
+			maxDepth: DefaultMaxUpgradePathDepth,
+			expected: [][]string{
+				{"4.16.1", "4.16.2", "4.16.4"},
+				{"4.16.1", "4.16.3", "4.16.4"},
+			},
+		},
+		{
+			name:     "max depth prunes longer paths",
+			releases: diamond,
+			from:     "4.16.1",
+			to:       "4.16.4",
+			maxDepth: 1,
+		},
+		{
+			name: "cycles are not followed",
+			releases: VersionReleases{
+				"4.16.1": Release{Version: "4.16.1", AvailableUpgrades: []string{"4.16.2"}},
+				"4.16.2": Release{Version: "4.16.2", AvailableUpgrades: []string{"4.16.1", "4.16.3"}},
+				"4.16.3": Release{Version: "4.16.3"},
+			},
+			from:     "4.16.1",
+			to:       "4.16.3",
+			maxDepth: DefaultMaxUpgradePathDepth,
+			expected: [][]string{{"4.16.1", "4.16.2", "4.16.3"}},
+		},
+		{
+			name:          "unknown source version",
+			releases:      diamond,
+			from:          "4.15.0",
+			to:            "4.16.4",
+			maxDepth:      DefaultMaxUpgradePathDepth,
+			expectedError: "unknown source version: 4.15.0",
+		},
+		{
+			name:          "unknown target version",
+			releases:      diamond,
+			from:          "4.16.1",
+			to:            "4.17.0",
+			maxDepth:      DefaultMaxUpgradePathDepth,
+			expectedError: "unknown target version: 4.17.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			paths, err := ListAllUpgradePathsWithMaxDepth(tc.releases, tc.from, tc.to, tc.maxDepth)
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, paths); diff != "" {
+				t.Errorf("Paths mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAvailableDowngradesFrom(t *testing.T) {
+	tests := []struct {
+		name                        string
+		allowedConditionalEdgeRisks []string
+		target                      string
+		expected                    []string
+	}{
+		{
+			name:                        "two incoming edges, one of them conditional",
+			allowedConditionalEdgeRisks: []string{"RiskA"},
+			target:                      "4.16.5",
+			expected:                    []string{"4.16.1", "4.16.2"},
+		},
+		{
+			name:     "conditional edge with a rejected risk is not reflected",
+			target:   "4.16.5",
+			expected: []string{"4.16.1"},
+		},
+		{
+			name:   "no incoming edges",
+			target: "4.16.1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hClient := fakeHTTPClientForFiles(t, map[string]string{
+				testGraphURL + "?arch=amd64&channel=stable-4.16": "testdata/discover-releases-stable-4.16-mixed-edges.json",
+			})
+			releases, err := New(hClient).DiscoverReleases(rawURLtoURLOrDie(testGraphURL), "stable-4.16", "amd64", tc.allowedConditionalEdgeRisks)
+			if err != nil {
+				t.Fatalf("Failed to discover releases: %v", err)
+			}
+
+			sources := AvailableDowngradesFrom(releases["stable-4.16"], tc.target)
+			if diff := cmp.Diff(tc.expected, sources); diff != "" {
+				t.Errorf("Sources mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAvailableDowngradesFromInvalidVersion(t *testing.T) {
+	releases := VersionReleases{
+		"4.16.10": Release{Version: "4.16.10", AvailableUpgrades: []string{"4.16.11"}},
+		"4.16.9":  Release{Version: "4.16.9", AvailableUpgrades: []string{"4.16.11"}},
+		"bogus":   Release{Version: "bogus", AvailableUpgrades: []string{"4.16.11"}},
+		"4.16.2":  Release{Version: "4.16.2", AvailableUpgrades: []string{"4.16.11"}},
+		"4.16.11": Release{Version: "4.16.11"},
+	}
+	expected := []string{"4.16.2", "4.16.9", "4.16.10", "bogus"}
+	if diff := cmp.Diff(expected, AvailableDowngradesFrom(releases, "4.16.11")); diff != "" {
+		t.Errorf("Sources mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestDetectCycles(t *testing.T) {
+	tests := []struct {
+		name     string
+		releases VersionReleases
+		expected [][]string
+	}{
+		{
+			name: "DAG has no cycles",
+			releases: VersionReleases{
+				"4.16.1": Release{Version: "4.16.1", AvailableUpgrades: []string{"4.16.3", "4.16.2"}},
+				"4.16.2": Release{Version: "4.16.2", AvailableUpgrades: []string{"4.16.4"}},
+				"4.16.3": Release{Version: "4.16.3", AvailableUpgrades: []string{"4.16.4"}},
+				"4.16.4": Release{Version: "4.16.4"},
+			},
+			expected: [][]string{},
+		},
+		{
+			name: "cycles are reported",
+			releases: VersionReleases{
+				"4.16.1": Release{Version: "4.16.1", AvailableUpgrades: []string{"4.16.2"}},
+				"4.16.2": Release{Version: "4.16.2", AvailableUpgrades: []string{"4.16.3", "4.16.1"}},
+				"4.16.3": Release{Version: "4.16.3", AvailableUpgrades: []string{"4.16.4"}},
+				"4.16.4": Release{Version: "4.16.4", AvailableUpgrades: []string{"4.16.2", "4.17.0"}},
+				"4.16.5": Release{Version: "4.16.5", AvailableUpgrades: []string{"4.16.5"}},
+			},
+			expected: [][]string{
+				{"4.16.1", "4.16.2"},
+				{"4.16.2", "4.16.3", "4.16.4"},
+				{"4.16.5"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, tc.releases.DetectCycles()); diff != "" {
+				t.Errorf("Cycles mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPlanEUSPath(t *testing.T) {
+	releases := VersionReleases{
+		"4.14.1": Release{Version: "4.14.1", AvailableUpgrades: []string{"4.15.1", "4.14.5"}},
+		"4.14.5": Release{Version: "4.14.5", AvailableUpgrades: []string{"4.16.1", "4.18.1"}},
+		"4.15.1": Release{Version: "4.15.1", AvailableUpgrades: []string{"4.16.1"}},
+		"4.16.1": Release{Version: "4.16.1", AvailableUpgrades: []string{"4.17.1"}},
+		"4.17.1": Release{Version: "4.17.1", AvailableUpgrades: []string{"4.18.1"}},
+		"4.18.1": Release{Version: "4.18.1"},
+	}
+
+	tests := []struct {
+		name          string
+		from          string
+		to            string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "stops on even minors only",
+			from:     "4.14.1",
+			to:       "4.16.1",
+			expected: []string{"4.14.1", "4.14.5", "4.16.1"},
+		},
+		{
+			name:          "hop skipping an even minor",
+			from:          "4.14.1",
+			to:            "4.18.1",
+			expectedError: "no EUS upgrade path from 4.14.1 to 4.18.1",
+		},
+		{
+			name:          "unknown target",
+			from:          "4.14.1",
+			to:            "4.20.0",
+			expectedError: "unknown target version: 4.20.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path, err := PlanEUSPath(releases, tc.from, tc.to)
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PlanEUSPath returned an error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, path); diff != "" {
+				t.Errorf("Path mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+
+	_, err := PlanEUSPath(releases, "4.14.1", "4.18.1")
+	var eusErr *EUSPathError
+	if !errors.As(err, &eusErr) {
+		t.Fatalf("Expected an *EUSPathError, got %v", err)
+	}
+}
+
+func TestMaxReachable(t *testing.T) {
+	releases := VersionReleases{
+		"4.15.3":  Release{Version: "4.15.3", AvailableUpgrades: []string{"4.16.1"}},
+		"4.16.1":  Release{Version: "4.16.1", AvailableUpgrades: []string{"4.16.2", "4.17.0"}},
+		"4.16.2":  Release{Version: "4.16.2", AvailableUpgrades: []string{"4.16.10"}},
+		"4.16.10": Release{Version: "4.16.10", AvailableUpgrades: []string{"4.17.4"}},
+		"4.17.0":  Release{Version: "4.17.0", AvailableUpgrades: []string{"4.17.2"}},
+		"4.17.2":  Release{Version: "4.17.2"},
+		"4.17.4":  Release{Version: "4.17.4", AvailableUpgrades: []string{"4.16.2"}},
+		"4.18.0":  Release{Version: "4.18.0"},
+	}
+
+	tests := []struct {
+		name          string
+		from          string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "across minors",
+			from:     "4.15.3",
+			expected: "4.17.4",
+		},
+		{
+			name:     "through a cycle",
+			from:     "4.17.4",
+			expected: "4.17.4",
+		},
+		{
+			name:     "shorter branch",
+			from:     "4.17.0",
+			expected: "4.17.2",
+		},
+		{
+			name:     "no outgoing edges",
+			from:     "4.18.0",
+			expected: "4.18.0",
+		},
+		{
+			name:          "unknown source",
+			from:          "4.14.0",
+			expectedError: "unknown source version: 4.14.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := releases.MaxReachable(tc.from)
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MaxReachable returned an error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
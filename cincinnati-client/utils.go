@@ -1,35 +1,246 @@
 package cincinnaticlient
 
 import (
+	"fmt"
+	"maps"
 	"slices"
+	"sort"
 	"strings"
+
+	"github.com/hashicorp/go-version"
 )
 
 func AggregateReleasesByChannelGroupAndSortAvailableUpgrades(releasesByChannel ReleasesByChannel) (ReleasesByChannel, error) {
+	return AggregateBy(releasesByChannel, ChannelPrefixGroup)
+}
+
+// ChannelPrefixGroup is the default grouper of AggregateBy,
+// it groups channels by the part of their name before the first "-", e.g. stable-4.16 → stable.
+func ChannelPrefixGroup(channel string) string {
+	if idx := strings.Index(channel, "-"); idx != -1 {
+		return channel[:idx]
+	}
+	return channel
+}
+
+// AggregateReleasesByMinor is like AggregateReleasesByChannelGroupAndSortAvailableUpgrades
+// but groups the channels by their major.minor version regardless of their tier,
+// e.g. stable-4.16 and fast-4.16 → 4.16.
+// Channels without a parseable version are kept under their full name.
+func AggregateReleasesByMinor(releasesByChannel ReleasesByChannel) (ReleasesByChannel, error) {
+	return AggregateBy(releasesByChannel, channelMinorGroup)
+}
+
+// channelMinorGroup returns the major.minor version of the channel, e.g. stable-4.16 → 4.16.
+// The channel is split like the start channel of a discovery, so suffixes such as "-ec" are dropped.
+func channelMinorGroup(channel string) string {
+	_, channelVersion, err := splitChannel(channel)
+	if err != nil {
+		return channel
+	}
+	v, err := version.NewVersion(channelVersion)
+	if err != nil {
+		return channel
+	}
+	segments := v.Segments()
+	return fmt.Sprintf("%d.%d", segments[0], segments[1])
+}
+
+// AggregateBy merges the releases of the channels mapped to the same key by the grouper,
+// and sorts their AvailableUpgrades. The returned ReleasesByChannel is keyed by group.
+func AggregateBy(releasesByChannel ReleasesByChannel, grouper func(channel string) string) (ReleasesByChannel, error) {
+	aggregated, _, err := AggregateByWithPayloadConflicts(releasesByChannel, grouper)
+	return aggregated, err
+}
+
+// AggregateByWithPayloadConflicts is like AggregateBy but also reports the versions
+// found with different payloads in the channels of a group, which is a sign of an inconsistent graph.
+// The conflicts map a version to its payloads, sorted and without duplicates.
+// The aggregated release keeps the payload of the first channel in sorted order.
+func AggregateByWithPayloadConflicts(releasesByChannel ReleasesByChannel, grouper func(channel string) string) (ReleasesByChannel, map[string][]string, error) {
 	aggregated := make(ReleasesByChannel)
-	for channel, versionMap := range releasesByChannel {
-		group := channel
-		if idx := strings.Index(channel, "-"); idx != -1 {
-			group = channel[:idx]
-		}
+	conflicts := make(map[string][]string)
+	// channels are visited in sorted order so that merging is deterministic
+	for _, channel := range slices.Sorted(maps.Keys(releasesByChannel)) {
+		versionMap := releasesByChannel[channel]
+		group := grouper(channel)
 		if aggregated[group] == nil {
 			aggregated[group] = make(VersionReleases)
 		}
 		for version, release := range versionMap {
 			releaseToAdd := release
 			if existing, exists := aggregated[group][version]; exists {
+				if existing.Payload != release.Payload {
+					conflicts[version] = appendPayloads(conflicts[version], existing.Payload, release.Payload)
+				}
 				for _, up := range release.AvailableUpgrades {
 					if !slices.Contains(existing.AvailableUpgrades, up) {
 						existing.AvailableUpgrades = append(existing.AvailableUpgrades, up)
 					}
 				}
+				existing.ConditionalUpgrades = mergeConditionalUpgrades(existing.ConditionalUpgrades, release.ConditionalUpgrades)
+				existing.Metadata = mergeMetadata(existing.Metadata, release.Metadata)
 				releaseToAdd = existing
 			}
 			if err := releaseToAdd.SortAvailableUpgrades(); err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			aggregated[group][version] = releaseToAdd
 		}
 	}
-	return aggregated, nil
+	return aggregated, conflicts, nil
+}
+
+// Merge returns the union of the channels and releases of dst and src, e.g. to combine the results
+// of discoveries from different start channels. The releases present in both are merged like
+// AggregateBy does: the upgrades, conditional upgrades and metadata of src missing from dst are added,
+// and the payload of dst wins. Neither dst nor src is modified.
+func Merge(dst, src ReleasesByChannel) ReleasesByChannel {
+	merged, _ := MergeWithPayloadConflicts(dst, src)
+	return merged
+}
+
+// MergeWithPayloadConflicts is like Merge but also reports the versions found with different payloads
+// in the same channel of dst and src. The conflicts map a version to its payloads, sorted and without duplicates.
+func MergeWithPayloadConflicts(dst, src ReleasesByChannel) (ReleasesByChannel, map[string][]string) {
+	merged := make(ReleasesByChannel, len(dst))
+	for channel, releases := range dst {
+		merged[channel] = maps.Clone(releases)
+	}
+	conflicts := make(map[string][]string)
+	for channel, releases := range src {
+		if merged[channel] == nil {
+			merged[channel] = make(VersionReleases, len(releases))
+		}
+		for version, release := range releases {
+			existing, exists := merged[channel][version]
+			if !exists {
+				merged[channel][version] = release
+				continue
+			}
+			if existing.Payload != release.Payload {
+				conflicts[version] = appendPayloads(conflicts[version], existing.Payload, release.Payload)
+			}
+			// the upgrades are cloned so that appending to them doesn't write to the array of dst
+			existing.AvailableUpgrades = slices.Clone(existing.AvailableUpgrades)
+			for _, up := range release.AvailableUpgrades {
+				if !slices.Contains(existing.AvailableUpgrades, up) {
+					existing.AvailableUpgrades = append(existing.AvailableUpgrades, up)
+				}
+			}
+			existing.ConditionalUpgrades = mergeConditionalUpgrades(existing.ConditionalUpgrades, release.ConditionalUpgrades)
+			existing.Metadata = mergeMetadata(existing.Metadata, release.Metadata)
+			merged[channel][version] = existing
+		}
+	}
+	return merged, conflicts
+}
+
+// appendPayloads appends the payloads not yet present in dst and keeps the result sorted.
+func appendPayloads(dst []string, payloads ...string) []string {
+	for _, payload := range payloads {
+		if !slices.Contains(dst, payload) {
+			dst = append(dst, payload)
+		}
+	}
+	slices.Sort(dst)
+	return dst
+}
+
+// mergeConditionalUpgrades returns a new map holding the targets of both dst and src.
+// The risks of targets present in both maps are merged without duplicates.
+func mergeConditionalUpgrades(dst, src map[string][]Risk) map[string][]Risk {
+	if len(src) == 0 {
+		return dst
+	}
+	merged := make(map[string][]Risk, len(dst)+len(src))
+	for target, risks := range dst {
+		merged[target] = slices.Clone(risks)
+	}
+	for target, risks := range src {
+		merged[target] = appendRisks(merged[target], risks...)
+	}
+	return merged
+}
+
+// appendRisks appends the risks whose names are not yet present in dst.
+func appendRisks(dst []Risk, risks ...Risk) []Risk {
+	for _, risk := range risks {
+		if !slices.ContainsFunc(dst, func(r Risk) bool { return r.Name == risk.Name }) {
+			dst = append(dst, risk)
+		}
+	}
+	return dst
+}
+
+// mergeMetadata returns a new map holding the keys of both dst and src.
+// Values already present in dst win, except for the release channels key
+// whose comma-separated channel lists are merged without duplicates.
+func mergeMetadata(dst, src map[string]string) map[string]string {
+	if len(src) == 0 {
+		return dst
+	}
+	merged := maps.Clone(dst)
+	if merged == nil {
+		merged = make(map[string]string, len(src))
+	}
+	for key, value := range src {
+		existing, exists := merged[key]
+		if !exists {
+			merged[key] = value
+			continue
+		}
+		if key == releaseChannelsMetadataKey {
+			merged[key] = mergeChannelLists(existing, value)
+		}
+	}
+	return merged
+}
+
+// mergeChannelLists merges two comma-separated channel lists, keeping the order of first appearance.
+func mergeChannelLists(a, b string) string {
+	var channels []string
+	for _, ch := range strings.Split(a+","+b, ",") {
+		ch = strings.TrimSpace(ch)
+		if ch != "" && !slices.Contains(channels, ch) {
+			channels = append(channels, ch)
+		}
+	}
+	return strings.Join(channels, ",")
+}
+
+// sortedVersions returns the version keys in ascending semantic-version order.
+func sortedVersions(v VersionReleases) []string {
+	versions := slices.Collect(maps.Keys(v))
+	SortVersions(versions)
+	return versions
+}
+
+// SortVersions sorts the given versions in ascending semantic-version order, equal versions
+// such as 4.16 and 4.16.0 in lexical order. Versions that cannot be parsed are placed last,
+// in lexical order, and are returned in that order so that callers can report them.
+func SortVersions(versions []string) (invalid []string) {
+	parsed := make(map[string]*version.Version, len(versions))
+	valid := 0
+	for _, v := range versions {
+		if ver, err := version.NewVersion(v); err == nil {
+			parsed[v] = ver
+			valid++
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		v1, v2 := parsed[versions[i]], parsed[versions[j]]
+		switch {
+		case v1 != nil && v2 != nil:
+			if cmp := v1.Compare(v2); cmp != 0 {
+				return cmp < 0
+			}
+			return versions[i] < versions[j]
+		case v1 == nil && v2 == nil:
+			return versions[i] < versions[j]
+		default:
+			return v1 != nil
+		}
+	})
+	return slices.Clone(versions[valid:])
 }
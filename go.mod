@@ -6,4 +6,5 @@ require (
 	github.com/Masterminds/semver/v3 v3.3.1
 	github.com/google/go-cmp v0.7.0
 	github.com/hashicorp/go-version v1.7.0
+	golang.org/x/time v0.12.0
 )
@@ -3,55 +3,229 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
-	"sort"
+	"os"
+	"slices"
+	"strings"
 
-	"github.com/Masterminds/semver/v3"
 	"github.com/p0lyn0mial/cincinnati-installation-versions/cincinnati-client"
 )
 
-func main() {
-	startChannel := flag.String("channel", "fast-4.16", "Starting channel (e.g. stable-4.16)")
-	flag.Parse()
+// outputFormats lists the supported values of the -output flag.
+var outputFormats = []string{"text", "json", "dot", "csv", "ndjson"}
+
+// channelPrefixes lists the channel prefixes accepted by the -channel flag.
+var channelPrefixes = []string{"stable", "fast", "candidate", "eus"}
+
+// envVars maps the flags that can also be set with environment variables to their variables.
+// A flag set on the command line takes precedence over its variable.
+var envVars = map[string]string{
+	"graph-url": "CINCINNATI_GRAPH_URL",
+	"channel":   "CINCINNATI_CHANNEL",
+	"arch":      "CINCINNATI_ARCH",
+}
+
+// config holds the command line configuration.
+type config struct {
+	graphURL                    *url.URL
+	startChannel                string
+	arch                        string
+	output                      string
+	minVersion                  string
+	allowedConditionalEdgeRisks []string
+}
+
+// stringSliceFlag is a repeatable flag that also accepts comma-separated values.
+type stringSliceFlag []string
+
+func (s *stringSliceFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringSliceFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*s = append(*s, v)
+		}
+	}
+	return nil
+}
+
+// parseFlags parses and validates the command line arguments,
+// reading the environment variables of the flags that aren't set with getenv.
+func parseFlags(args []string, getenv func(string) string) (*config, error) {
+	fs := flag.NewFlagSet("cincinnati-installation-versions", flag.ContinueOnError)
+	fs.String("graph-url", cincinnaticlient.DefaultGraphURL, "URL of the Cincinnati graph API, defaults to $CINCINNATI_GRAPH_URL")
+	fs.String("channel", "fast-4.16", "Starting channel (e.g. stable-4.16), prefixed with one of: "+strings.Join(channelPrefixes, ", ")+", defaults to $CINCINNATI_CHANNEL")
+	fs.String("arch", "multi", "Architecture of the releases: "+strings.Join(cincinnaticlient.SupportedArchs, ", ")+", defaults to $CINCINNATI_ARCH")
+	minVersion := fs.String("min-version", "", "Minimum version of the releases (e.g. 4.16.5), defaults to the version of the channel")
+	output := fs.String("output", "text", "Output format: text, json, dot, csv or ndjson")
+	var allowedRisks stringSliceFlag
+	fs.Var(&allowedRisks, "allow-risk", "Conditional edge risk to accept (repeatable or comma-separated)")
+	allowAllRisks := fs.Bool("allow-all-risks", false, "Accept every conditional edge regardless of its risks")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	resolved := resolveConfig(fs, getenv)
 
-	u, err := url.Parse("https://api.openshift.com/api/upgrades_info/graph")
+	graphURL, err := url.Parse(resolved["graph-url"])
 	if err != nil {
-		fmt.Printf("error parsing URL: %s\n", err)
-		return
+		return nil, fmt.Errorf("invalid graph URL %q: %w", resolved["graph-url"], err)
+	}
+	if err := validateChannelPrefix(resolved["channel"]); err != nil {
+		return nil, err
+	}
+	normalizedArch, err := validateArch(resolved["arch"])
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(outputFormats, *output) {
+		return nil, fmt.Errorf("unsupported output format %q, expected one of: %s", *output, strings.Join(outputFormats, ", "))
+	}
+
+	cfg := &config{
+		graphURL:                    graphURL,
+		startChannel:                resolved["channel"],
+		arch:                        normalizedArch,
+		output:                      *output,
+		minVersion:                  *minVersion,
+		allowedConditionalEdgeRisks: allowedRisks,
+	}
+	if *allowAllRisks {
+		cfg.allowedConditionalEdgeRisks = []string{cincinnaticlient.AllConditionalEdgeRisks}
+	}
+	return cfg, nil
+}
+
+// resolveConfig returns the values of the flags listed in envVars: the value of a flag set
+// on the command line, else the value of its environment variable if it is not empty,
+// else the default of the flag.
+func resolveConfig(fs *flag.FlagSet, getenv func(string) string) map[string]string {
+	resolved := make(map[string]string, len(envVars))
+	for name, envVar := range envVars {
+		resolved[name] = fs.Lookup(name).DefValue
+		if value := getenv(envVar); value != "" {
+			resolved[name] = value
+		}
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if _, ok := envVars[f.Name]; ok {
+			resolved[f.Name] = f.Value.String()
+		}
+	})
+	return resolved
+}
+
+func main() {
+	cfg, err := parseFlags(os.Args[1:], os.Getenv)
+	if err != nil {
+		if err == flag.ErrHelp {
+			return
+		}
+		exitWithError("%v", err)
 	}
 
-	var allowedConditionalEdgeRisks []string
 	hClient := &http.Client{}
 
-	cincinnatiClient := cincinnaticlient.New(hClient)
-	multiArchReleasesByChannel, err := cincinnatiClient.DiscoverReleases(u, *startChannel, "multi", allowedConditionalEdgeRisks)
+	var opts []cincinnaticlient.Option
+	if cfg.minVersion != "" {
+		opts = append(opts, cincinnaticlient.WithMinVersion(cfg.minVersion))
+	}
+	cincinnatiClient := cincinnaticlient.New(hClient, opts...)
+	multiArchReleasesByChannel, err := cincinnatiClient.DiscoverReleases(cfg.graphURL, cfg.startChannel, cfg.arch, cfg.allowedConditionalEdgeRisks)
 	if err != nil {
-		fmt.Printf("error discovering releases from %s: %v\n", *startChannel, err)
-		return
+		exitWithError("error discovering releases from %s: %v", cfg.startChannel, err)
 	}
 
 	aggregatedMultiArchReleasesByChannelGroup, err := cincinnaticlient.AggregateReleasesByChannelGroupAndSortAvailableUpgrades(multiArchReleasesByChannel)
 	if err != nil {
-		fmt.Printf("error aggregating releases from %s: %v\n", *startChannel, err)
+		exitWithError("error aggregating releases from %s: %v", cfg.startChannel, err)
+	}
+
+	switch cfg.output {
+	case "json":
+		if err := aggregatedMultiArchReleasesByChannelGroup.WriteJSON(os.Stdout); err != nil {
+			exitWithError("error writing JSON output: %v", err)
+		}
+		return
+	case "dot":
+		if err := aggregatedMultiArchReleasesByChannelGroup.WriteDOT(os.Stdout); err != nil {
+			exitWithError("error writing DOT output: %v", err)
+		}
+		return
+	case "csv":
+		if err := aggregatedMultiArchReleasesByChannelGroup.WriteCSV(os.Stdout); err != nil {
+			exitWithError("error writing CSV output: %v", err)
+		}
+		return
+	case "ndjson":
+		if err := aggregatedMultiArchReleasesByChannelGroup.WriteNDJSON(os.Stdout); err != nil {
+			exitWithError("error writing NDJSON output: %v", err)
+		}
 		return
 	}
 
-	fmt.Println("\nAggregated releases by channel group (prefix) with unique versions:")
-	for group, versionsMap := range aggregatedMultiArchReleasesByChannelGroup {
-		fmt.Printf("Group: %s\n", group)
+	if err := renderReleases(os.Stdout, os.Stderr, aggregatedMultiArchReleasesByChannelGroup); err != nil {
+		exitWithError("error writing output: %v", err)
+	}
+}
+
+// exitWithError writes the error message to stderr and exits with status 1,
+// so that errors don't end up in the JSON, DOT, CSV or NDJSON written to stdout.
+func exitWithError(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
+
+// renderReleases writes the aggregated releases in the default text format,
+// the groups in lexical order and their versions in ascending semantic-version order.
+// Warnings about versions that cannot be parsed are written to warnings rather than w,
+// so that they can be kept apart from the output, e.g. main passes os.Stderr.
+func renderReleases(w, warnings io.Writer, aggregated cincinnaticlient.ReleasesByChannel) error {
+	if _, err := fmt.Fprintln(w, "\nAggregated releases by channel group (prefix) with unique versions:"); err != nil {
+		return err
+	}
+	for _, group := range aggregated.SortedChannels() {
+		versionsMap := aggregated[group]
+		if _, err := fmt.Fprintf(w, "Group: %s\n", group); err != nil {
+			return err
+		}
 		versions := make([]string, 0, len(versionsMap))
 		for ver := range versionsMap {
 			versions = append(versions, ver)
 		}
-		sort.Slice(versions, func(i, j int) bool {
-			v1, _ := semver.NewVersion(versions[i])
-			v2, _ := semver.NewVersion(versions[j])
-			return v1.Compare(v2) < 0
-		})
+		sortVersions(warnings, versions)
 		for _, ver := range versions {
 			release := versionsMap[ver]
-			fmt.Printf("  Version: %s, Payload: %s, Arch: %s, AvailableUpgrades: %s\n", ver, release.Payload, release.Arch, release.AvailableUpgrades)
+			if _, err := fmt.Fprintf(w, "  Version: %s, Payload: %s, Arch: %s, AvailableUpgrades: %s\n", ver, release.Payload, release.Arch, release.AvailableUpgrades); err != nil {
+				return err
+			}
 		}
 	}
+	return nil
+}
+
+// validateArch checks that the arch is supported by the graph API and returns its normalized name.
+func validateArch(arch string) (string, error) {
+	return cincinnaticlient.NormalizeArch(arch)
+}
+
+// validateChannelPrefix checks that the channel starts with one of the known channel prefixes,
+// so that typos like stabel-4.16 don't silently yield empty results.
+func validateChannelPrefix(channel string) error {
+	prefix, _, _ := strings.Cut(channel, "-")
+	if !slices.Contains(channelPrefixes, prefix) {
+		return fmt.Errorf("unsupported channel prefix %q in channel %q, expected one of: %s", prefix, channel, strings.Join(channelPrefixes, ", "))
+	}
+	return nil
+}
+
+// sortVersions sorts the versions like cincinnaticlient.SortVersions
+// and writes a warning to w for each version that cannot be parsed.
+func sortVersions(w io.Writer, versions []string) {
+	for _, ver := range cincinnaticlient.SortVersions(versions) {
+		fmt.Fprintf(w, "warning: invalid semantic version %q\n", ver)
+	}
 }
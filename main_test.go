@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/p0lyn0mial/cincinnati-installation-versions/cincinnati-client"
+)
+
+func TestValidateArch(t *testing.T) {
+	tests := []struct {
+		name          string
+		arch          string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "valid arch",
+			arch:     "arm64",
+			expected: "arm64",
+		},
+		{
+			name:     "arch alias",
+			arch:     "x86_64",
+			expected: "amd64",
+		},
+		{
+			name:          "invalid arch",
+			arch:          "amd-64",
+			expectedError: `unsupported arch "amd-64", expected one of: amd64, arm64, ppc64le, s390x, multi`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			arch, err := validateArch(tc.arch)
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if arch != tc.expected {
+				t.Errorf("Expected arch %q, got %q", tc.expected, arch)
+			}
+		})
+	}
+}
+
+func TestValidateChannelPrefix(t *testing.T) {
+	tests := []struct {
+		name          string
+		channel       string
+		expectedError string
+	}{
+		{
+			name:    "stable",
+			channel: "stable-4.16",
+		},
+		{
+			name:    "candidate",
+			channel: "candidate-4.17",
+		},
+		{
+			name:    "eus",
+			channel: "eus-4.16",
+		},
+		{
+			name:          "typo",
+			channel:       "stabel-4.16",
+			expectedError: `unsupported channel prefix "stabel" in channel "stabel-4.16", expected one of: stable, fast, candidate, eus`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateChannelPrefix(tc.channel)
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	defaultGraphURL, _ := url.Parse(cincinnaticlient.DefaultGraphURL)
+	mirrorGraphURL, _ := url.Parse("https://mirror.example.com/graph")
+	tests := []struct {
+		name          string
+		args          []string
+		env           map[string]string
+		expected      *config
+		expectedError string
+	}{
+		{
+			name: "defaults",
+			expected: &config{
+				graphURL:     defaultGraphURL,
+				startChannel: "fast-4.16",
+				arch:         "multi",
+				output:       "text",
+			},
+		},
+		{
+			name: "repeated and comma-separated allowed risks",
+			args: []string{"-channel", "stable-4.16", "-allow-risk", "RiskA", "-allow-risk", "RiskB,RiskC"},
+			expected: &config{
+				graphURL:                    defaultGraphURL,
+				startChannel:                "stable-4.16",
+				arch:                        "multi",
+				output:                      "text",
+				allowedConditionalEdgeRisks: []string{"RiskA", "RiskB", "RiskC"},
+			},
+		},
+		{
+			name: "all risks allowed",
+			args: []string{"-allow-risk", "RiskA", "-allow-all-risks"},
+			expected: &config{
+				graphURL:                    defaultGraphURL,
+				startChannel:                "fast-4.16",
+				arch:                        "multi",
+				output:                      "text",
+				allowedConditionalEdgeRisks: []string{cincinnaticlient.AllConditionalEdgeRisks},
+			},
+		},
+		{
+			name: "environment variables",
+			args: []string{"-arch", "arm64"},
+			env: map[string]string{
+				"CINCINNATI_GRAPH_URL": "https://mirror.example.com/graph",
+				"CINCINNATI_CHANNEL":   "stable-4.17",
+				"CINCINNATI_ARCH":      "s390x",
+			},
+			expected: &config{
+				graphURL:     mirrorGraphURL,
+				startChannel: "stable-4.17",
+				arch:         "arm64",
+				output:       "text",
+			},
+		},
+		{
+			name:          "unsupported channel prefix from the environment",
+			env:           map[string]string{"CINCINNATI_CHANNEL": "stabel-4.16"},
+			expectedError: `unsupported channel prefix "stabel"`,
+		},
+		{
+			name: "min version",
+			args: []string{"-channel", "stable-4.16", "-min-version", "4.16.5"},
+			expected: &config{
+				graphURL:     defaultGraphURL,
+				startChannel: "stable-4.16",
+				arch:         "multi",
+				output:       "text",
+				minVersion:   "4.16.5",
+			},
+		},
+		{
+			name:          "unsupported output format",
+			args:          []string{"-output", "yaml"},
+			expectedError: `unsupported output format "yaml"`,
+		},
+		{
+			name:          "unsupported channel prefix",
+			args:          []string{"-channel", "stabel-4.16"},
+			expectedError: `unsupported channel prefix "stabel"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := parseFlags(tc.args, func(key string) string { return tc.env[key] })
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, but got none", tc.expectedError)
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, cfg, cmp.AllowUnexported(config{})); diff != "" {
+				t.Errorf("Config mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResolveConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		expected map[string]string
+	}{
+		{
+			name: "defaults",
+			expected: map[string]string{
+				"graph-url": "https://default.example.com/graph",
+				"channel":   "fast-4.16",
+				"arch":      "multi",
+			},
+		},
+		{
+			name: "environment over defaults",
+			env: map[string]string{
+				"CINCINNATI_GRAPH_URL": "https://env.example.com/graph",
+				"CINCINNATI_CHANNEL":   "stable-4.17",
+			},
+			expected: map[string]string{
+				"graph-url": "https://env.example.com/graph",
+				"channel":   "stable-4.17",
+				"arch":      "multi",
+			},
+		},
+		{
+			name: "flags over environment",
+			args: []string{"-channel", "eus-4.16", "-arch", "amd64"},
+			env: map[string]string{
+				"CINCINNATI_GRAPH_URL": "https://env.example.com/graph",
+				"CINCINNATI_CHANNEL":   "stable-4.17",
+				"CINCINNATI_ARCH":      "arm64",
+			},
+			expected: map[string]string{
+				"graph-url": "https://env.example.com/graph",
+				"channel":   "eus-4.16",
+				"arch":      "amd64",
+			},
+		},
+		{
+			name: "flag set to its default over environment",
+			args: []string{"-channel", "fast-4.16"},
+			env:  map[string]string{"CINCINNATI_CHANNEL": "stable-4.17"},
+			expected: map[string]string{
+				"graph-url": "https://default.example.com/graph",
+				"channel":   "fast-4.16",
+				"arch":      "multi",
+			},
+		},
+		{
+			name: "empty environment variable",
+			env:  map[string]string{"CINCINNATI_ARCH": ""},
+			expected: map[string]string{
+				"graph-url": "https://default.example.com/graph",
+				"channel":   "fast-4.16",
+				"arch":      "multi",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.String("graph-url", "https://default.example.com/graph", "")
+			fs.String("channel", "fast-4.16", "")
+			fs.String("arch", "multi", "")
+			if err := fs.Parse(tc.args); err != nil {
+				t.Fatalf("Failed to parse flags: %v", err)
+			}
+			got := resolveConfig(fs, func(key string) string { return tc.env[key] })
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("Resolved config mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSortVersions(t *testing.T) {
+	versions := []string{"4.16.10", "not-a-version", "4.16.9", "4.16.1"}
+	var warnings bytes.Buffer
+
+	sortVersions(&warnings, versions)
+
+	expected := []string{"4.16.1", "4.16.9", "4.16.10", "not-a-version"}
+	if diff := cmp.Diff(expected, versions); diff != "" {
+		t.Errorf("Versions mismatch (-expected +got):\n%s", diff)
+	}
+	if !strings.Contains(warnings.String(), `warning: invalid semantic version "not-a-version"`) {
+		t.Errorf("Expected a warning for the invalid version, got %q", warnings.String())
+	}
+}
+
+func TestRenderReleases(t *testing.T) {
+	aggregated := cincinnaticlient.ReleasesByChannel{
+		"stable": {
+			"4.16.10": cincinnaticlient.Release{Version: "4.16.10", Arch: "amd64", Payload: "p-4.16.10"},
+			"4.16.9":  cincinnaticlient.Release{Version: "4.16.9", Arch: "amd64", Payload: "p-4.16.9", AvailableUpgrades: []string{"4.16.10"}},
+			"invalid": cincinnaticlient.Release{Version: "invalid", Arch: "amd64", Payload: "p-invalid"},
+		},
+		"fast": {
+			"4.16.9": cincinnaticlient.Release{Version: "4.16.9", Arch: "amd64", Payload: "p-4.16.9"},
+		},
+	}
+	var out, warnings bytes.Buffer
+
+	if err := renderReleases(&out, &warnings, aggregated); err != nil {
+		t.Fatalf("renderReleases returned an error: %v", err)
+	}
+
+	expected := `
+Aggregated releases by channel group (prefix) with unique versions:
+Group: fast
+  Version: 4.16.9, Payload: p-4.16.9, Arch: amd64, AvailableUpgrades: []
+Group: stable
+  Version: 4.16.9, Payload: p-4.16.9, Arch: amd64, AvailableUpgrades: [4.16.10]
+  Version: 4.16.10, Payload: p-4.16.10, Arch: amd64, AvailableUpgrades: []
+  Version: invalid, Payload: p-invalid, Arch: amd64, AvailableUpgrades: []
+`
+	if diff := cmp.Diff(expected, out.String()); diff != "" {
+		t.Errorf("Rendered releases mismatch (-expected +got):\n%s", diff)
+	}
+	if !strings.Contains(warnings.String(), `warning: invalid semantic version "invalid"`) {
+		t.Errorf("Expected a warning for the invalid version, got %q", warnings.String())
+	}
+}